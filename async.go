@@ -0,0 +1,228 @@
+package logger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Overflow decides what an AsyncHandler does with a record when its queue is full.
+type Overflow string
+
+const (
+	OverflowBlock      Overflow = "block"            // wait for room in the queue
+	OverflowDropNewest Overflow = "drop_newest"      // drop the record being logged
+	OverflowDropOldest Overflow = "drop_oldest"      // drop the oldest queued record to make room
+	OverflowDropBelow  Overflow = "drop_below_level" // drop records below DropLevel, block for the others
+)
+
+type AsyncConfig struct {
+	QueueSize     int           `json:"queue_size" yaml:"queue_size"`         // default 1024 records
+	BatchSize     int           `json:"batch_size" yaml:"batch_size"`         // default 64KB, bytes buffered before writing
+	FlushInterval time.Duration `json:"flush_interval" yaml:"flush_interval"` // default 1s
+	Overflow      Overflow      `json:"overflow" yaml:"overflow"`             // default block
+	DropLevel     slog.Level    `json:"drop_level" yaml:"drop_level"`         // only used for drop_below_level, default info
+}
+
+// AsyncHandler hands records over to a background goroutine through a bounded
+// queue, so a slow writer does not stall the logging goroutines. The goroutine
+// encodes them with the wrapped handler into a batch buffer, written out when
+// it reaches BatchSize bytes, every FlushInterval, on Sync and on Close.
+type AsyncHandler struct {
+	next  slog.Handler
+	state *asyncState
+}
+
+type asyncState struct {
+	conf    AsyncConfig
+	w       *batchWriter
+	queue   chan asyncRecord
+	syncCh  chan chan error
+	done    chan struct{}
+	mu      sync.RWMutex // held for writing while closing the queue
+	closed  bool
+	dropped atomic.Uint64
+}
+
+type asyncRecord struct {
+	h   slog.Handler
+	ctx context.Context
+	r   slog.Record
+}
+
+// NewAsyncHandler creates an AsyncHandler writing to w, build creates the
+// handler encoding the records, it is given the batch buffer in front of w.
+func NewAsyncHandler(w io.Writer, conf AsyncConfig, build func(w io.Writer) slog.Handler) *AsyncHandler {
+	if conf.QueueSize <= 0 {
+		conf.QueueSize = 1024
+	}
+	if conf.BatchSize <= 0 {
+		conf.BatchSize = 64 * 1024
+	}
+	if conf.FlushInterval <= 0 {
+		conf.FlushInterval = time.Second
+	}
+	if conf.Overflow == "" {
+		conf.Overflow = OverflowBlock
+	}
+
+	s := &asyncState{
+		conf:   conf,
+		w:      &batchWriter{w: w, size: conf.BatchSize, buf: make([]byte, 0, conf.BatchSize)},
+		queue:  make(chan asyncRecord, conf.QueueSize),
+		syncCh: make(chan chan error),
+		done:   make(chan struct{}),
+	}
+	go s.run()
+	return &AsyncHandler{next: build(s.w), state: s}
+}
+
+func (h *AsyncHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *AsyncHandler) Handle(ctx context.Context, r slog.Record) error {
+	h.state.enqueue(asyncRecord{h: h.next, ctx: ctx, r: r.Clone()})
+	return nil
+}
+
+func (h *AsyncHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &AsyncHandler{next: h.next.WithAttrs(attrs), state: h.state}
+}
+
+func (h *AsyncHandler) WithGroup(name string) slog.Handler {
+	return &AsyncHandler{next: h.next.WithGroup(name), state: h.state}
+}
+
+// Dropped returns the number of records dropped because the queue was full or the handler closed.
+func (h *AsyncHandler) Dropped() uint64 {
+	return h.state.dropped.Load()
+}
+
+// Sync waits until the records queued so far are written.
+func (h *AsyncHandler) Sync() error {
+	s := h.state
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return nil
+	}
+	ch := make(chan error, 1)
+	s.syncCh <- ch
+	return <-ch
+}
+
+// Close writes the queued records and stops the background goroutine,
+// records handled afterward are dropped.
+func (h *AsyncHandler) Close() error {
+	s := h.state
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	s.mu.Unlock()
+	<-s.done
+	return s.w.err
+}
+
+func (s *asyncState) enqueue(rec asyncRecord) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		s.dropped.Add(1)
+		return
+	}
+
+	overflow := s.conf.Overflow
+	if overflow == OverflowDropBelow {
+		overflow = OverflowBlock
+		if rec.r.Level < s.conf.DropLevel {
+			overflow = OverflowDropNewest
+		}
+	}
+
+	switch overflow {
+	case OverflowDropNewest:
+		select {
+		case s.queue <- rec:
+		default:
+			s.dropped.Add(1)
+		}
+	case OverflowDropOldest:
+		for {
+			select {
+			case s.queue <- rec:
+				return
+			default:
+			}
+			select {
+			case <-s.queue:
+				s.dropped.Add(1)
+			default:
+			}
+		}
+	default:
+		s.queue <- rec
+	}
+}
+
+func (s *asyncState) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.conf.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case rec, ok := <-s.queue:
+			if !ok {
+				s.w.Flush()
+				return
+			}
+			_ = rec.h.Handle(rec.ctx, rec.r)
+		case <-ticker.C:
+			s.w.Flush()
+		case ch := <-s.syncCh:
+			for n := len(s.queue); n > 0; n-- {
+				rec := <-s.queue
+				_ = rec.h.Handle(rec.ctx, rec.r)
+			}
+			ch <- s.w.Flush()
+		}
+	}
+}
+
+// batchWriter buffers writes up to size bytes. Unlike bufio.Writer it keeps
+// accepting writes after a failed one, it is only used by the async goroutine.
+type batchWriter struct {
+	w    io.Writer
+	buf  []byte
+	size int
+	err  error // last write error
+}
+
+func (b *batchWriter) Write(p []byte) (int, error) {
+	if len(b.buf) > 0 && len(b.buf)+len(p) > b.size {
+		b.Flush()
+	}
+	if len(p) >= b.size {
+		_, err := b.w.Write(p)
+		b.err = err
+		return len(p), nil
+	}
+	b.buf = append(b.buf, p...)
+	return len(p), nil
+}
+
+func (b *batchWriter) Flush() error {
+	if len(b.buf) == 0 {
+		return nil
+	}
+	_, err := b.w.Write(b.buf)
+	b.buf = b.buf[:0]
+	b.err = err
+	return err
+}
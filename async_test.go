@@ -0,0 +1,155 @@
+package logger
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// syncBuffer is a bytes.Buffer safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// gateWriter blocks every write until a value is sent on gate.
+type gateWriter struct {
+	gate chan struct{}
+	syncBuffer
+}
+
+func (w *gateWriter) Write(p []byte) (int, error) {
+	<-w.gate
+	return w.syncBuffer.Write(p)
+}
+
+func newTextAsync(w io.Writer, conf AsyncConfig) *AsyncHandler {
+	return NewAsyncHandler(w, conf, func(w io.Writer) slog.Handler {
+		return slog.NewTextHandler(w, &slog.HandlerOptions{
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if len(groups) == 0 && a.Key == slog.TimeKey {
+					return slog.Attr{}
+				}
+				return a
+			},
+		})
+	})
+}
+
+func TestAsyncHandler_Batch(t *testing.T) {
+	var buf syncBuffer
+	h := newTextAsync(&buf, AsyncConfig{FlushInterval: time.Hour})
+	l := slog.New(h).With("app", "demo")
+
+	l.Info("one")
+	l.WithGroup("g").Info("two", "k", "v")
+	time.Sleep(10 * time.Millisecond)
+	assert.Empty(t, buf.String(), "records are buffered until a flush")
+
+	require.NoError(t, h.Sync())
+	assert.Equal(t, "level=INFO msg=one app=demo\nlevel=INFO msg=two app=demo g.k=v\n", buf.String())
+
+	l.Info("three")
+	require.NoError(t, h.Close())
+	assert.Contains(t, buf.String(), "msg=three")
+
+	l.Info("after close")
+	assert.NotContains(t, buf.String(), "after close")
+	assert.Equal(t, uint64(1), h.Dropped())
+	assert.NoError(t, h.Sync())
+}
+
+func TestAsyncHandler_FlushOnSizeAndInterval(t *testing.T) {
+	var buf syncBuffer
+	h := newTextAsync(&buf, AsyncConfig{BatchSize: 64, FlushInterval: 20 * time.Millisecond})
+	defer h.Close()
+	l := slog.New(h)
+
+	l.Info(strings.Repeat("x", 100))
+	assert.Eventually(t, func() bool { return strings.Contains(buf.String(), "xxx") }, time.Second, time.Millisecond)
+
+	l.Info("small")
+	assert.Eventually(t, func() bool { return strings.Contains(buf.String(), "msg=small") }, time.Second, time.Millisecond)
+}
+
+func TestAsyncHandler_Overflow(t *testing.T) {
+	for _, tt := range []struct {
+		overflow Overflow
+		want     []string
+		dropped  uint64
+	}{
+		{OverflowDropNewest, []string{"m0", "m1", "m2"}, 3},
+		{OverflowDropOldest, []string{"m0", "m4", "m5"}, 3},
+		{OverflowDropBelow, []string{"m0", "m1", "m2", "m5"}, 2},
+	} {
+		t.Run(string(tt.overflow), func(t *testing.T) {
+			w := &gateWriter{gate: make(chan struct{})}
+			h := newTextAsync(w, AsyncConfig{QueueSize: 2, BatchSize: 1, Overflow: tt.overflow, DropLevel: slog.LevelWarn})
+			l := slog.New(h)
+
+			l.Info("m0")
+			// wait until the goroutine is blocked writing m0, leaving the queue empty
+			assert.Eventually(t, func() bool { return len(h.state.queue) == 0 }, time.Second, time.Millisecond)
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				l.Info("m1")
+				l.Info("m2")
+				l.Info("m3")
+				l.Info("m4")
+				l.Warn("m5")
+			}()
+			if tt.overflow == OverflowDropBelow {
+				// m3 and m4 are dropped, m5 blocks until there is room
+				assert.Eventually(t, func() bool { return h.Dropped() == 2 }, time.Second, time.Millisecond)
+				w.gate <- struct{}{}
+			}
+			<-done
+
+			close(w.gate)
+			require.NoError(t, h.Close())
+
+			var got []string
+			for _, line := range strings.Split(strings.TrimSpace(w.String()), "\n") {
+				got = append(got, strings.TrimPrefix(line[strings.Index(line, "msg="):], "msg="))
+			}
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.dropped, h.Dropped())
+		})
+	}
+}
+
+func TestLogger_Async(t *testing.T) {
+	var buf syncBuffer
+	l, err := Open(&Config{Mode: ModeCustom, Writer: &buf, Async: &AsyncConfig{FlushInterval: time.Hour}})
+	require.NoError(t, err)
+
+	l.Info("hello")
+	require.NoError(t, l.Sync())
+	assert.Contains(t, buf.String(), `"msg":"hello"`)
+
+	l.Info("bye")
+	require.NoError(t, l.Close())
+	assert.Contains(t, buf.String(), `"msg":"bye"`)
+	assert.Equal(t, uint64(0), l.Dropped())
+}
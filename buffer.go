@@ -0,0 +1,95 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+// BufferSize is the most records held by the buffer of a BufferContext, the
+// oldest are dropped beyond it.
+var BufferSize = 1000
+
+// BufferLevel is the lowest level of the records held by the buffer of a
+// BufferContext, records below it are discarded as without a buffer.
+var BufferLevel = slog.LevelDebug
+
+type bufferCtxKey struct{}
+
+// debugBuffer holds the records not enabled by the level of the loggers built
+// by New, logged with the context of a BufferContext.
+type debugBuffer struct {
+	level   slog.Level // BufferLevel when the scope started
+	done    atomic.Bool
+	mu      sync.Mutex
+	records []bufferedRecord
+}
+
+type bufferedRecord struct {
+	h   slog.Handler
+	ctx context.Context
+	r   slog.Record
+}
+
+// BufferContext returns a copy of ctx scoping a buffer: records logged with it
+// at BufferLevel and above that the level of the logger would discard, such as
+// DEBUG records at the INFO level, are held in the buffer instead. They are written, in order, right
+// before the next ERROR record logged with the context, otherwise they are
+// discarded when the scope ends. end ends the scope, writing the held records
+// first when flush is true. It fits sloghttp.Config.Buffer to scope the buffer
+// to HTTP requests.
+func BufferContext(ctx context.Context) (_ context.Context, end func(flush bool)) {
+	b := &debugBuffer{level: BufferLevel}
+	return context.WithValue(ctx, bufferCtxKey{}, b), func(flush bool) {
+		records := b.take(true)
+		if flush {
+			writeBuffered(records)
+		}
+	}
+}
+
+// bufferFrom returns the buffer of ctx, nil if it has none or its scope ended.
+func bufferFrom(ctx context.Context) *debugBuffer {
+	b, _ := ctx.Value(bufferCtxKey{}).(*debugBuffer)
+	if b == nil || b.done.Load() {
+		return nil
+	}
+	return b
+}
+
+// holds reports whether the buffer holds the records of level.
+func (b *debugBuffer) holds(level slog.Level) bool {
+	return level >= b.level
+}
+
+func (b *debugBuffer) add(h slog.Handler, ctx context.Context, r slog.Record) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.done.Load() {
+		return
+	}
+	if len(b.records) >= max(BufferSize, 1) {
+		b.records[0] = bufferedRecord{}
+		b.records = b.records[1:]
+	}
+	b.records = append(b.records, bufferedRecord{h: h, ctx: ctx, r: r.Clone()})
+}
+
+// take empties the buffer and returns the held records, end ends its scope.
+func (b *debugBuffer) take(end bool) []bufferedRecord {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	records := b.records
+	b.records = nil
+	if end {
+		b.done.Store(true)
+	}
+	return records
+}
+
+func writeBuffered(records []bufferedRecord) {
+	for _, rec := range records {
+		_ = rec.h.Handle(rec.ctx, rec.r)
+	}
+}
@@ -0,0 +1,103 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/goapt/logger/sloghttp"
+)
+
+func messagesOf(buf *bytes.Buffer) []string {
+	var got []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if i := strings.Index(line, `"msg":"`); i >= 0 {
+			msg := line[i+len(`"msg":"`):]
+			got = append(got, msg[:strings.IndexByte(msg, '"')])
+		}
+	}
+	return got
+}
+
+func TestBufferContext(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&Config{Mode: ModeCustom, Writer: &buf})
+
+	ctx, end := BufferContext(context.Background())
+	l.DebugContext(ctx, "step 1")
+	l.With("k", "v").DebugContext(ctx, "step 2")
+	l.InfoContext(ctx, "info")
+	l.Debug("no context")
+	assert.Equal(t, []string{"info"}, messagesOf(&buf))
+
+	l.ErrorContext(ctx, "failed")
+	assert.Equal(t, []string{"info", "step 1", "step 2", "failed"}, messagesOf(&buf))
+	assert.Contains(t, buf.String(), `"msg":"step 2","k":"v"`)
+
+	l.DebugContext(ctx, "step 3")
+	end(false)
+	l.DebugContext(ctx, "after end")
+	l.ErrorContext(ctx, "failed again")
+	assert.Equal(t, []string{"info", "step 1", "step 2", "failed", "failed again"}, messagesOf(&buf))
+
+	buf.Reset()
+	ctx, end = BufferContext(context.Background())
+	l.DebugContext(ctx, "kept")
+	end(true)
+	assert.Equal(t, []string{"kept"}, messagesOf(&buf))
+}
+
+func TestBufferContext_Size(t *testing.T) {
+	defer func(n int) { BufferSize = n }(BufferSize)
+	BufferSize = 2
+
+	var buf bytes.Buffer
+	l := New(&Config{Mode: ModeCustom, Writer: &buf})
+	ctx, end := BufferContext(context.Background())
+	l.DebugContext(ctx, "1")
+	l.DebugContext(ctx, "2")
+	l.DebugContext(ctx, "3")
+	end(true)
+	assert.Equal(t, []string{"2", "3"}, messagesOf(&buf))
+}
+
+func TestBufferContext_Level(t *testing.T) {
+	defer func(level slog.Level) { BufferLevel = level }(BufferLevel)
+	BufferLevel = slog.LevelInfo
+
+	var buf bytes.Buffer
+	l := New(&Config{Mode: ModeCustom, Writer: &buf, Level: slog.LevelWarn})
+	ctx, end := BufferContext(context.Background())
+	assert.False(t, l.Enabled(ctx, slog.LevelDebug), "records below BufferLevel are not built")
+	assert.True(t, l.Enabled(ctx, slog.LevelInfo))
+	l.DebugContext(ctx, "debug")
+	l.InfoContext(ctx, "info")
+	end(true)
+	assert.Equal(t, []string{"info"}, messagesOf(&buf))
+	assert.False(t, l.Enabled(ctx, slog.LevelInfo), "the scope ended")
+}
+
+func TestBufferContext_Middleware(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&Config{Mode: ModeCustom, Writer: &buf})
+
+	conf := sloghttp.DefaultConfig
+	conf.Buffer = BufferContext
+	h := sloghttp.NewMiddleware(l, conf)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l.DebugContext(r.Context(), "debug "+r.URL.Path)
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ok", nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fail", nil))
+	require.Equal(t, []string{"200: OK", "debug /fail", "502: Bad Gateway"}, messagesOf(&buf))
+}
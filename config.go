@@ -0,0 +1,427 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadConfig reads the config file at path, then applies the LOG_* environment
+// variables on top of it and validates the result. Files ending in .json are
+// decoded as JSON, anything else as YAML. An empty path configures the logger
+// from the environment only.
+//
+// The environment variables are LOG_MODE, LOG_FORMAT, LOG_LEVEL, LOG_FILENAME,
+// LOG_MAX_FILES, LOG_MAX_SIZE, LOG_DETAIL, LOG_FALLBACK, LOG_TIME_FORMAT, LOG_TIME_ZONE
+// and LOG_LEVELS, the latter a comma separated list of named logger overrides such
+// as "billing.*=debug,cache=warn".
+func LoadConfig(path string) (*Config, error) {
+	conf := &Config{}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read log config: %w", err)
+		}
+		if err := decodeConfig(path, data, conf); err != nil {
+			return nil, fmt.Errorf("decode log config %s: %w", path, err)
+		}
+	}
+	if err := conf.applyEnv(); err != nil {
+		return nil, err
+	}
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+func decodeConfig(path string, data []byte, conf *Config) error {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		// accept durations as strings such as "1s", as YAML does
+		var raw any
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		if err := dec.Decode(&raw); err != nil {
+			return err
+		}
+		if err := parseJSONDurations(raw, reflect.TypeOf(conf)); err != nil {
+			return err
+		}
+		data, _ = json.Marshal(raw)
+		dec = json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		return dec.Decode(conf)
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(conf); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+var durationType = reflect.TypeFor[time.Duration]()
+
+// parseJSONDurations replaces the strings decoded from JSON in v for the
+// time.Duration fields of t by their number of nanoseconds.
+func parseJSONDurations(v any, t reflect.Type) error {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch v := v.(type) {
+	case map[string]any:
+		for key, fv := range v {
+			ft := t
+			if t.Kind() == reflect.Struct {
+				f, ok := jsonField(t, key)
+				if !ok {
+					continue
+				}
+				ft = f.Type
+			} else if t.Kind() == reflect.Map {
+				ft = t.Elem()
+			} else {
+				return nil
+			}
+			if s, ok := fv.(string); ok && ft == durationType {
+				d, err := time.ParseDuration(s)
+				if err != nil {
+					return fmt.Errorf("%s: %w", key, err)
+				}
+				v[key] = int64(d)
+				continue
+			}
+			if err := parseJSONDurations(fv, ft); err != nil {
+				return err
+			}
+		}
+	case []any:
+		if t.Kind() != reflect.Slice {
+			return nil
+		}
+		for _, e := range v {
+			if err := parseJSONDurations(e, t.Elem()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// jsonField returns the field of the struct t decoded from the JSON key,
+// looking into embedded structs as encoding/json does.
+func jsonField(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" && f.Anonymous && f.Type.Kind() == reflect.Struct {
+			if ef, ok := jsonField(f.Type, key); ok {
+				return ef, true
+			}
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if strings.EqualFold(name, key) {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func (c *Config) applyEnv() error {
+	var errs []error
+	env := func(key string, set func(string) error) {
+		if v, ok := os.LookupEnv(key); ok {
+			if err := set(v); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", key, err))
+			}
+		}
+	}
+
+	env("LOG_MODE", func(v string) error { c.Mode = Mode(v); return nil })
+	env("LOG_FORMAT", func(v string) error { c.Format = Format(v); return nil })
+	env("LOG_LEVEL", func(v string) error { return c.Level.UnmarshalText([]byte(v)) })
+	env("LOG_FILENAME", func(v string) error { c.FileName = v; return nil })
+	env("LOG_MAX_FILES", func(v string) (err error) { c.MaxFiles, err = strconv.Atoi(v); return err })
+	env("LOG_MAX_SIZE", func(v string) (err error) { c.MaxSize, err = strconv.ParseInt(v, 10, 64); return err })
+	env("LOG_DETAIL", func(v string) (err error) { c.Detail, err = strconv.ParseBool(v); return err })
+	env("LOG_FALLBACK", func(v string) error { c.Fallback = Fallback(v); return nil })
+	env("LOG_TIME_FORMAT", func(v string) error { c.TimeFormat = v; return nil })
+	env("LOG_TIME_ZONE", func(v string) error { c.TimeZone = v; return nil })
+	env("LOG_LEVELS", func(v string) error {
+		levels, err := parseLevels(v)
+		if err != nil {
+			return err
+		}
+		c.Levels = levels
+		return nil
+	})
+
+	return errors.Join(errs...)
+}
+
+// parseLevels parses "billing.*=debug,cache=warn".
+func parseLevels(s string) (map[string]slog.Level, error) {
+	levels := make(map[string]slog.Level)
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid level override %q, want name=level", item)
+		}
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(strings.TrimSpace(value))); err != nil {
+			return nil, err
+		}
+		levels[strings.TrimSpace(name)] = level
+	}
+	return levels, nil
+}
+
+// Validate reports the invalid settings of c.
+func (c *Config) Validate() error {
+	var errs []error
+
+	switch c.Fallback {
+	case "", FallbackFail, FallbackStderr:
+	default:
+		errs = append(errs, fmt.Errorf("unknown fallback %q", c.Fallback))
+	}
+
+	if _, err := c.replaceAttr(); err != nil {
+		errs = append(errs, err)
+	}
+	if c.Sampling != nil {
+		errs = append(errs, c.Sampling.validate()...)
+	}
+	if c.Ring != nil && (c.Ring.Size < 0 || c.Ring.MaxBytes < 0) {
+		errs = append(errs, errors.New("ring size and max_bytes cannot be negative"))
+	}
+	if c.Errors != nil && (c.Errors.StackDepth < 0 || c.Errors.MaxCauses < 0) {
+		errs = append(errs, errors.New("errors stack_depth and max_causes cannot be negative"))
+	}
+	if c.Redact != nil {
+		if _, err := c.Redact.rules(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(c.Sinks) == 0 {
+		sink := c.sink()
+		errs = append(errs, sink.validate()...)
+	}
+	for i := range c.Sinks {
+		for _, err := range c.Sinks[i].validate() {
+			errs = append(errs, fmt.Errorf("sinks[%d]: %w", i, err))
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("invalid log config: %w", err)
+	}
+	return nil
+}
+
+func (s *SinkConfig) validate() []error {
+	var errs []error
+
+	switch s.Mode {
+	case "", ModeStd, ModeStderr, ModeFile, ModeJournal:
+	case ModeCustom:
+		if s.Writer == nil {
+			errs = append(errs, errors.New("custom mode requires a Writer"))
+		}
+	case ModeNetwork:
+		errs = append(errs, s.Network.validate()...)
+	case ModeSyslog:
+		var conf SyslogConfig
+		if s.Syslog != nil {
+			conf = *s.Syslog
+		}
+		errs = append(errs, conf.validate()...)
+	case ModeOTLP:
+		if s.OTLP != nil {
+			errs = append(errs, s.OTLP.validate()...)
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown mode %q", s.Mode))
+	}
+
+	switch s.Format {
+	case "", FormatJSON, FormatText, FormatLogfmt, FormatConsole:
+	case FormatGELF:
+		if s.GELF != nil {
+			errs = append(errs, s.GELF.validate(s.Network)...)
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown format %q", s.Format))
+	}
+
+	if s.Async != nil {
+		switch s.Async.Overflow {
+		case "", OverflowBlock, OverflowDropNewest, OverflowDropOldest, OverflowDropBelow:
+		default:
+			errs = append(errs, fmt.Errorf("unknown async overflow %q", s.Async.Overflow))
+		}
+		if s.Async.QueueSize < 0 || s.Async.BatchSize < 0 || s.Async.FlushInterval < 0 {
+			errs = append(errs, errors.New("async queue_size, batch_size and flush_interval cannot be negative"))
+		}
+	}
+
+	if s.MaxFiles < 0 {
+		errs = append(errs, fmt.Errorf("max_files cannot be negative, got %d", s.MaxFiles))
+	}
+	if s.MaxSize < 0 {
+		errs = append(errs, fmt.Errorf("max_size cannot be negative, got %d", s.MaxSize))
+	}
+	return errs
+}
+
+func (n *NetworkConfig) validate() []error {
+	if n == nil || n.Address == "" {
+		return []error{errors.New("network mode requires a network address")}
+	}
+	var errs []error
+	switch n.Network {
+	case "", "tcp", "tcp4", "tcp6":
+	case "udp", "udp4", "udp6", "unix", "unixgram":
+		if n.TLS != nil {
+			errs = append(errs, fmt.Errorf("tls requires a tcp network, got %q", n.Network))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown network %q", n.Network))
+	}
+	if n.DialTimeout < 0 || n.WriteTimeout < 0 || n.MinBackoff < 0 || n.MaxBackoff < 0 || n.SpoolSize < 0 {
+		errs = append(errs, errors.New("network timeouts, backoffs and spool_size cannot be negative"))
+	}
+	return errs
+}
+
+func (c *GELFConfig) validate(network *NetworkConfig) []error {
+	var errs []error
+	switch c.Compression {
+	case "", GELFCompressNone:
+	case GELFCompressGzip, GELFCompressZlib:
+		if network != nil && !isDatagram(network.Network) {
+			errs = append(errs, fmt.Errorf("gelf compression %s requires a udp network", c.Compression))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown gelf compression %q", c.Compression))
+	}
+	if c.ChunkSize != 0 && c.ChunkSize <= 12 {
+		errs = append(errs, fmt.Errorf("gelf chunk_size must be larger than the 12 bytes chunk header, got %d", c.ChunkSize))
+	}
+	return errs
+}
+
+func (c SyslogConfig) validate() []error {
+	c = c.withDefaults()
+	var errs []error
+	if _, ok := syslogFacilities[c.Facility]; !ok {
+		errs = append(errs, fmt.Errorf("unknown syslog facility %q", c.Facility))
+	}
+	if c.RFC != RFC5424 && c.RFC != RFC3164 {
+		errs = append(errs, fmt.Errorf("unknown syslog rfc %q", c.RFC))
+	}
+	return append(errs, c.NetworkConfig.validate()...)
+}
+
+func (s *SamplingConfig) validate() []error {
+	var errs []error
+	if s.Interval < 0 {
+		errs = append(errs, errors.New("sampling interval cannot be negative"))
+	}
+	if s.First < 0 || s.Thereafter < 0 {
+		errs = append(errs, errors.New("sampling first and thereafter cannot be negative"))
+	}
+	for level, rule := range s.Levels {
+		if rule.First < 0 || rule.Thereafter < 0 {
+			errs = append(errs, fmt.Errorf("sampling first and thereafter of level %s cannot be negative", level))
+		}
+	}
+	return errs
+}
+
+// Watch polls the config file at path every interval and, whenever it changes,
+// reloads it with LoadConfig and atomically swaps the handlers behind Default()
+// for those of a logger built from it, along with its levels. The loggers
+// derived from Default() before, such as those of Named, follow the swap.
+// Default() is only replaced when it was not built by New. The logger
+// replaced by a reload is closed after the swap, except the one in place when
+// Watch was called which is left to its owner. A config that fails to load is
+// reported on the current default logger and the previous logger is kept,
+// levels included. Watch blocks until ctx is done.
+func Watch(ctx context.Context, path string, interval time.Duration) error {
+	last, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("watch log config: %w", err)
+	}
+	return watch(ctx, path, last, interval, nil)
+}
+
+// watch is Watch from the file info last, calling reloaded, if not nil, with
+// the result of every reload.
+func watch(ctx context.Context, path string, last os.FileInfo, interval time.Duration, reloaded func(error)) error {
+	var current *Logger
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		fi, err := os.Stat(path)
+		if err != nil || (fi.ModTime().Equal(last.ModTime()) && fi.Size() == last.Size()) {
+			continue
+		}
+		last = fi
+
+		l, err := reload(path)
+		if err != nil {
+			Default().Error("reload log config failed", slog.String("path", path), slog.Any("error", err))
+		} else {
+			if h, ok := Default().Handler().(*levelHandler); ok && h.levels != nil {
+				h.swap(l.Handler().(*levelHandler))
+			} else {
+				SetDefault(l.Logger)
+			}
+			if current != nil {
+				_ = current.Close()
+			}
+			current = l
+		}
+		if reloaded != nil {
+			reloaded(err)
+		}
+	}
+}
+
+func reload(path string) (*Logger, error) {
+	conf, err := LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	return Open(conf)
+}
@@ -0,0 +1,169 @@
+package logger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	yml := filepath.Join(dir, "log.yaml")
+	require.NoError(t, os.WriteFile(yml, []byte(`
+mode: file
+format: logfmt
+level: warn
+filename: /var/log/app.log
+max_files: 5
+detail: true
+levels:
+  billing.*: debug
+`), 0644))
+
+	conf, err := LoadConfig(yml)
+	require.NoError(t, err)
+	assert.Equal(t, ModeFile, conf.Mode)
+	assert.Equal(t, FormatLogfmt, conf.Format)
+	assert.Equal(t, slog.LevelWarn, conf.Level)
+	assert.Equal(t, "/var/log/app.log", conf.FileName)
+	assert.Equal(t, 5, conf.MaxFiles)
+	assert.True(t, conf.Detail)
+	assert.Equal(t, map[string]slog.Level{"billing.*": slog.LevelDebug}, conf.Levels)
+
+	js := filepath.Join(dir, "log.json")
+	require.NoError(t, os.WriteFile(js, []byte(`{"mode":"std","level":"ERROR","max_size":1024}`), 0644))
+
+	t.Setenv("LOG_LEVEL", "debug")
+	t.Setenv("LOG_MAX_FILES", "7")
+	t.Setenv("LOG_LEVELS", "cache=warn, billing.*=info")
+	conf, err = LoadConfig(js)
+	require.NoError(t, err)
+	assert.Equal(t, ModeStd, conf.Mode)
+	assert.Equal(t, slog.LevelDebug, conf.Level)
+	assert.Equal(t, int64(1024), conf.MaxSize)
+	assert.Equal(t, 7, conf.MaxFiles)
+	assert.Equal(t, map[string]slog.Level{"cache": slog.LevelWarn, "billing.*": slog.LevelInfo}, conf.Levels)
+}
+
+func TestLoadConfig_JSONDurations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"sampling": {"interval": "2s", "first": 5},
+		"async": {"flush_interval": 250000000},
+		"sinks": [{"mode": "network", "network": {"address": "localhost:514", "dial_timeout": "1m"}}],
+		"fields": {"timeout": "3s"}
+	}`), 0644))
+	conf, err := LoadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2*time.Second, conf.Sampling.Interval)
+	assert.Equal(t, 5, conf.Sampling.First)
+	assert.Equal(t, 250*time.Millisecond, conf.Async.FlushInterval)
+	assert.Equal(t, time.Minute, conf.Sinks[0].Network.DialTimeout)
+	assert.Equal(t, "3s", conf.Fields["timeout"], "only duration fields are parsed")
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"sampling": {"interval": "soon"}}`), 0644))
+	_, err = LoadConfig(path)
+	assert.ErrorContains(t, err, `interval: time: invalid duration "soon"`)
+}
+
+func TestLoadConfig_Invalid(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "log.yml")
+	require.NoError(t, os.WriteFile(path, []byte("mode: kafka\nmax_size: -1\n"), 0644))
+	_, err := LoadConfig(path)
+	assert.ErrorContains(t, err, `unknown mode "kafka"`)
+	assert.ErrorContains(t, err, "max_size cannot be negative")
+
+	require.NoError(t, os.WriteFile(path, []byte("mdoe: std\n"), 0644))
+	_, err = LoadConfig(path)
+	assert.ErrorContains(t, err, "field mdoe not found")
+
+	t.Setenv("LOG_DETAIL", "maybe")
+	_, err = LoadConfig("")
+	assert.ErrorContains(t, err, "LOG_DETAIL")
+}
+
+func TestWatch(t *testing.T) {
+	old := Default()
+	defer SetDefault(old)
+	// the reloads swap the handlers of Default() in place
+	SetDefault(New(&Config{Mode: ModeCustom, Writer: io.Discard}))
+	current := Default()
+	named := Named("billing")
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "log.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("level: warn\n"), 0644))
+	last, err := os.Stat(path)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	reloaded := make(chan error)
+	go func() { done <- watch(ctx, path, last, time.Millisecond, func(err error) { reloaded <- err }) }()
+	next := func() error {
+		select {
+		case err := <-reloaded:
+			return err
+		case <-time.After(5 * time.Second):
+			t.Fatal("config not reloaded")
+			return nil
+		}
+	}
+
+	// each write is atomic and changes the size, so it is seen once whatever the mtime resolution
+	write := func(conf string) {
+		require.NoError(t, os.WriteFile(path+".tmp", []byte(conf), 0644))
+		require.NoError(t, os.Rename(path+".tmp", path))
+	}
+	read := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	// the loggers derived from Default() before the reloads follow them
+	for _, name := range []string{"first.log", "second.log"} {
+		write("level: debug\nformat: logfmt\nmode: file\nfilename: " + filepath.Join(dir, name) + "\n")
+		require.NoError(t, next())
+		assert.Same(t, current, Default())
+		assert.Equal(t, slog.LevelDebug, LevelVar().Level())
+		named.Debug("to " + name)
+	}
+	assert.Contains(t, read("first.log"), `msg="to first.log"`)
+	assert.NotContains(t, read("first.log"), `msg="to second.log"`)
+	assert.Contains(t, read("second.log"), `logger=billing`)
+	assert.Contains(t, read("second.log"), `msg="to second.log"`)
+
+	// and so do the level changes made after them
+	SetNamedLevel("billing", slog.LevelError)
+	named.Warn("hidden")
+	assert.NotContains(t, read("second.log"), "hidden")
+
+	// an invalid config keeps the current logger
+	write("format: xml\n")
+	assert.ErrorContains(t, next(), `unknown format "xml"`)
+	assert.Same(t, current, Default())
+
+	// so does a config failing to open, with its levels
+	blocker := filepath.Join(dir, "blocker")
+	require.NoError(t, os.WriteFile(blocker, nil, 0644))
+	write("level: error\nlevels: {billing.*: debug}\nmode: file\nfilename: " + filepath.Join(blocker, "app.log") + "\n")
+	assert.ErrorContains(t, next(), "new file writer error")
+	assert.Equal(t, slog.LevelDebug, LevelVar().Level())
+	assert.Equal(t, map[string]slog.Level{"billing": slog.LevelError}, NamedLevels())
+	named.Error("kept")
+	assert.Contains(t, read("second.log"), `msg=kept`)
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
@@ -0,0 +1,328 @@
+package logger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/text/width"
+)
+
+const FormatConsole Format = "console"
+
+const (
+	ansiReset   = "\x1b[0m"
+	ansiBold    = "\x1b[1m"
+	ansiDim     = "\x1b[2m"
+	ansiRed     = "\x1b[31m"
+	ansiGreen   = "\x1b[32m"
+	ansiYellow  = "\x1b[33m"
+	ansiMagenta = "\x1b[35m"
+	ansiCyan    = "\x1b[36m"
+)
+
+// consoleMessageWidth is the width messages are padded to so attrs line up.
+const consoleMessageWidth = 40
+
+type ConsoleOptions struct {
+	slog.HandlerOptions
+	// Color enables ANSI colors, see colorEnabled for the auto detection used by Config.
+	Color bool
+}
+
+// ConsoleHandler is a slog.Handler for developer consoles. Every record is
+// written as one aligned line with a level badge, followed by one indented
+// line per attribute group:
+//
+//	15:04:05.000 INF 200: OK                                   request_id=5c0a
+//	  request method=GET path=/hello
+//	  response status=200 latency=1.2ms
+type ConsoleHandler struct {
+	opts   ConsoleOptions
+	pre    []consoleAttrs // attrs added by WithAttrs, with the groups open at that time
+	groups []string
+	mu     *sync.Mutex
+	w      io.Writer
+}
+
+type consoleAttrs struct {
+	groups []string
+	attrs  []slog.Attr
+}
+
+// consoleGroup is the tree of attrs rendered for a single record.
+type consoleGroup struct {
+	name   string
+	attrs  []slog.Attr
+	groups []*consoleGroup
+}
+
+// NewConsoleHandler creates a ConsoleHandler that writes to w, using the given options.
+// If opts is nil, the default options are used.
+func NewConsoleHandler(w io.Writer, opts *ConsoleOptions) *ConsoleHandler {
+	if opts == nil {
+		opts = &ConsoleOptions{}
+	}
+	return &ConsoleHandler{opts: *opts, mu: &sync.Mutex{}, w: w}
+}
+
+func (h *ConsoleHandler) Enabled(_ context.Context, level slog.Level) bool {
+	minLevel := slog.LevelInfo
+	if h.opts.Level != nil {
+		minLevel = h.opts.Level.Level()
+	}
+	return level >= minLevel
+}
+
+func (h *ConsoleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	h2 := *h
+	h2.pre = append(h.pre[:len(h.pre):len(h.pre)], consoleAttrs{groups: h.groups, attrs: attrs})
+	return &h2
+}
+
+func (h *ConsoleHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.groups = append(h.groups[:len(h.groups):len(h.groups)], name)
+	return &h2
+}
+
+func (h *ConsoleHandler) Handle(_ context.Context, r slog.Record) error {
+	root := &consoleGroup{}
+	for _, p := range h.pre {
+		for _, a := range p.attrs {
+			h.addAttr(root.child(p.groups), p.groups, a)
+		}
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		h.addAttr(root.child(h.groups), h.groups, a)
+		return true
+	})
+
+	buf := make([]byte, 0, 1024)
+	if !r.Time.IsZero() {
+		if a, ok := h.builtin(slog.Time(slog.TimeKey, r.Time)); ok {
+			buf = h.appendColored(buf, ansiDim, func(b []byte) []byte { return appendBareValue(b, a.Value) })
+			buf = append(buf, ' ')
+		}
+	}
+	buf = h.appendLevel(buf, r.Level)
+	buf = append(buf, ' ')
+	buf = append(buf, r.Message...)
+
+	if len(root.attrs) > 0 {
+		if pad := consoleMessageWidth - displayWidth(r.Message); pad > 0 {
+			buf = append(buf, strings.Repeat(" ", pad)...)
+		}
+		buf = h.appendAttrs(buf, root.attrs)
+	}
+	if h.opts.AddSource && r.PC != 0 {
+		if a, ok := h.builtin(slog.Any(slog.SourceKey, recordSource(r))); ok {
+			buf = append(buf, ' ')
+			buf = h.appendColored(buf, ansiDim, func(b []byte) []byte { return appendBareValue(b, a.Value) })
+		}
+	}
+	for _, g := range root.groups {
+		buf = h.appendGroup(buf, g, 1)
+	}
+	buf = append(buf, '\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.w.Write(buf)
+	return err
+}
+
+// builtin applies ReplaceAttr to a built-in attr.
+func (h *ConsoleHandler) builtin(a slog.Attr) (slog.Attr, bool) {
+	if h.opts.ReplaceAttr != nil {
+		a = h.opts.ReplaceAttr(nil, a)
+		a.Value = a.Value.Resolve()
+	}
+	return a, !a.Equal(slog.Attr{})
+}
+
+func (h *ConsoleHandler) addAttr(g *consoleGroup, groups []string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if h.opts.ReplaceAttr != nil && a.Value.Kind() != slog.KindGroup {
+		a = h.opts.ReplaceAttr(groups, a)
+		a.Value = a.Value.Resolve()
+	}
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() != slog.KindGroup {
+		g.attrs = append(g.attrs, a)
+		return
+	}
+
+	attrs := a.Value.Group()
+	if len(attrs) == 0 {
+		return
+	}
+	if a.Key != "" {
+		groups = append(groups[:len(groups):len(groups)], a.Key)
+		g = g.child([]string{a.Key})
+	}
+	for _, ga := range attrs {
+		h.addAttr(g, groups, ga)
+	}
+}
+
+func (h *ConsoleHandler) appendGroup(buf []byte, g *consoleGroup, depth int) []byte {
+	if len(g.attrs) == 0 && len(g.groups) == 0 {
+		return buf
+	}
+	buf = append(buf, '\n')
+	buf = append(buf, strings.Repeat("  ", depth)...)
+	buf = h.appendColored(buf, ansiBold, func(b []byte) []byte { return append(b, g.name...) })
+	buf = h.appendAttrs(buf, g.attrs)
+	for _, sub := range g.groups {
+		buf = h.appendGroup(buf, sub, depth+1)
+	}
+	return buf
+}
+
+func (h *ConsoleHandler) appendAttrs(buf []byte, attrs []slog.Attr) []byte {
+	for _, a := range attrs {
+		buf = append(buf, ' ')
+		color := ansiCyan
+		if isErrorAttr(a) {
+			color = ansiRed
+		}
+		buf = h.appendColored(buf, color, func(b []byte) []byte { return append(appendLogfmtKey(b, a.Key), '=') })
+		if color == ansiRed {
+			buf = h.appendColored(buf, ansiRed, func(b []byte) []byte { return appendConsoleValue(b, a.Value) })
+		} else {
+			buf = appendConsoleValue(buf, a.Value)
+		}
+	}
+	return buf
+}
+
+func (h *ConsoleHandler) appendLevel(buf []byte, level slog.Level) []byte {
+	var badge, color string
+	switch {
+	case level >= slog.LevelError:
+		badge, color = "ERR", ansiRed+ansiBold
+	case level >= slog.LevelWarn:
+		badge, color = "WRN", ansiYellow+ansiBold
+	case level >= slog.LevelInfo:
+		badge, color = "INF", ansiGreen+ansiBold
+	default:
+		badge, color = "DBG", ansiMagenta+ansiBold
+	}
+	if level != levelBase(level) {
+		badge = level.String()
+	}
+	return h.appendColored(buf, color, func(b []byte) []byte { return append(b, badge...) })
+}
+
+func (h *ConsoleHandler) appendColored(buf []byte, color string, f func([]byte) []byte) []byte {
+	if !h.opts.Color {
+		return f(buf)
+	}
+	buf = append(buf, color...)
+	buf = f(buf)
+	return append(buf, ansiReset...)
+}
+
+func (g *consoleGroup) child(path []string) *consoleGroup {
+	for _, name := range path {
+		var next *consoleGroup
+		for _, c := range g.groups {
+			if c.name == name {
+				next = c
+				break
+			}
+		}
+		if next == nil {
+			next = &consoleGroup{name: name}
+			g.groups = append(g.groups, next)
+		}
+		g = next
+	}
+	return g
+}
+
+func appendConsoleValue(buf []byte, v slog.Value) []byte {
+	if v.Kind() == slog.KindString {
+		// strings are only quoted when ambiguous, a bare value reads better on a console
+		s := v.String()
+		if s != "" && !strings.ContainsAny(s, " \t\r\n\"=") {
+			return append(buf, s...)
+		}
+	}
+	return appendLogfmtValue(buf, v)
+}
+
+// appendBareValue appends v without quoting, used for the built-in time and source.
+func appendBareValue(buf []byte, v slog.Value) []byte {
+	if v.Kind() == slog.KindString {
+		return append(buf, v.String()...)
+	}
+	return appendConsoleValue(buf, v)
+}
+
+// levelBase rounds level down to the nearest of the four named slog levels.
+func levelBase(level slog.Level) slog.Level {
+	switch {
+	case level >= slog.LevelError:
+		return slog.LevelError
+	case level >= slog.LevelWarn:
+		return slog.LevelWarn
+	case level >= slog.LevelInfo:
+		return slog.LevelInfo
+	default:
+		return slog.LevelDebug
+	}
+}
+
+func isErrorAttr(a slog.Attr) bool {
+	if a.Key == "error" || a.Key == "err" {
+		return true
+	}
+	if a.Value.Kind() == slog.KindAny {
+		_, ok := a.Value.Any().(error)
+		return ok
+	}
+	return false
+}
+
+// isTerminal reports whether w is a character device such as a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// colorEnabled reports whether ANSI colors should be written to w, honoring https://no-color.org.
+func colorEnabled(w io.Writer) bool {
+	return os.Getenv("NO_COLOR") == "" && isTerminal(w)
+}
+
+// displayWidth returns the number of terminal columns taken by s, two for
+// East Asian wide and fullwidth runes and one for the others.
+func displayWidth(s string) int {
+	n := 0
+	for _, r := range s {
+		switch width.LookupRune(r).Kind() {
+		case width.EastAsianWide, width.EastAsianFullwidth:
+			n += 2
+		default:
+			n++
+		}
+	}
+	return n
+}
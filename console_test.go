@@ -0,0 +1,90 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsoleHandler(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewConsoleHandler(&buf, &ConsoleOptions{
+		HandlerOptions: slog.HandlerOptions{
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if a.Value.Kind() == slog.KindTime {
+					return slog.String(a.Key, a.Value.Time().Format("15:04:05.000"))
+				}
+				return a
+			},
+		},
+	})
+
+	slog.New(h).With("request_id", "abc").Error("200: OK",
+		slog.Any("error", errors.New("boom")),
+		slog.Group("request", slog.String("method", "GET"), slog.Group("header", slog.String("Accept", "*/*"))),
+		slog.Group("response", slog.Int("status", 200), slog.Duration("latency", time.Millisecond)),
+	)
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	assert.Len(t, lines, 4)
+	assert.Regexp(t, `^\d\d:\d\d:\d\d\.\d{3} ERR 200: OK {33} request_id=abc error=boom$`, lines[0])
+	assert.Equal(t, "  request method=GET", lines[1])
+	assert.Equal(t, "    header Accept=*/*", lines[2])
+	assert.Equal(t, "  response status=200 latency=1ms", lines[3])
+}
+
+func TestConsoleHandler_Padding(t *testing.T) {
+	var buf bytes.Buffer
+	l := slog.New(NewConsoleHandler(&buf, &ConsoleOptions{
+		HandlerOptions: slog.HandlerOptions{
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if len(groups) == 0 && a.Key == slog.TimeKey {
+					return slog.Attr{}
+				}
+				return a
+			},
+		},
+	}))
+	l.Info("订单已创建", "id", 1)
+	l.Info("abcde", "id", 2)
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	assert.Len(t, lines, 2)
+	// the attrs start at the same column
+	assert.Equal(t, displayWidth(lines[1][:strings.Index(lines[1], "id=")]), displayWidth(lines[0][:strings.Index(lines[0], "id=")]))
+}
+
+func TestDisplayWidth(t *testing.T) {
+	assert.Equal(t, 5, displayWidth("abcde"))
+	assert.Equal(t, 10, displayWidth("订单已创建"))
+	assert.Equal(t, 4, displayWidth("ＡＢ"))
+	assert.Equal(t, 3, displayWidth("été"))
+}
+
+func TestConsoleHandler_Color(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewConsoleHandler(&buf, &ConsoleOptions{Color: true})
+
+	slog.New(h).Warn("careful", "err", "disk full", "n", 1)
+
+	got := buf.String()
+	assert.Contains(t, got, ansiYellow+ansiBold+"WRN"+ansiReset)
+	assert.Contains(t, got, ansiRed+"err="+ansiReset+ansiRed+`"disk full"`+ansiReset)
+	assert.Contains(t, got, ansiCyan+"n="+ansiReset+"1")
+}
+
+func TestColorEnabled(t *testing.T) {
+	var buf bytes.Buffer
+	assert.False(t, colorEnabled(&buf))
+
+	f, err := os.CreateTemp(t.TempDir(), "log")
+	assert.NoError(t, err)
+	defer f.Close()
+	assert.False(t, isTerminal(f))
+}
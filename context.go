@@ -0,0 +1,121 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/goapt/logger/sloghttp"
+)
+
+// ContextHandler adds the values carried by the context of a record to it:
+// the sloghttp request ID, the OpenTelemetry trace and span IDs and the
+// attributes stored with sloghttp.NewContextAttributes. They are added at the
+// top level, outside the groups opened by WithGroup, and a key the record or
+// the logger already has there is not added again. The groups are kept by the
+// handler and passed to next as group attrs of the records.
+type ContextHandler struct {
+	next   slog.Handler        // with the attrs added before the first group
+	keys   map[string]struct{} // keys added by WithAttrs before the first group
+	groups []contextGroup      // opened by WithGroup, innermost last
+}
+
+// contextGroup is a group opened by WithGroup with the attrs added in it.
+type contextGroup struct {
+	name  string
+	attrs []slog.Attr
+}
+
+// NewContextHandler creates a ContextHandler sending the records to next.
+func NewContextHandler(next slog.Handler) *ContextHandler {
+	return &ContextHandler{next: next}
+}
+
+func (h *ContextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *ContextHandler) Handle(ctx context.Context, r slog.Record) error {
+	seen := func(key string) bool {
+		if _, ok := h.keys[key]; ok {
+			return true
+		}
+		if len(h.groups) > 0 {
+			// the attrs of the record are in the groups
+			return false
+		}
+		found := false
+		r.Attrs(func(a slog.Attr) bool {
+			found = a.Key == key
+			return !found
+		})
+		return found
+	}
+
+	var attrs []slog.Attr
+	add := func(a slog.Attr) {
+		if !seen(a.Key) {
+			attrs = append(attrs, a)
+		}
+	}
+	if id := sloghttp.GetRequestIDFromContext(ctx); id != "" {
+		add(slog.String(sloghttp.RequestIDKey, id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		add(slog.String(sloghttp.TraceIDKey, sc.TraceID().String()))
+		add(slog.String(sloghttp.SpanIDKey, sc.SpanID().String()))
+	}
+	for _, a := range sloghttp.GetContextAttributes(ctx) {
+		add(a)
+	}
+
+	if len(h.groups) == 0 {
+		if len(attrs) > 0 {
+			r = r.Clone()
+			r.AddAttrs(attrs...)
+		}
+		return h.next.Handle(ctx, r)
+	}
+
+	inner := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		inner = append(inner, a)
+		return true
+	})
+	for i := len(h.groups) - 1; i >= 0; i-- {
+		g := h.groups[i]
+		inner = []slog.Attr{{Key: g.name, Value: slog.GroupValue(append(slices.Clip(g.attrs), inner...)...)}}
+	}
+	nr := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	nr.AddAttrs(inner...)
+	nr.AddAttrs(attrs...)
+	return h.next.Handle(ctx, nr)
+}
+
+func (h *ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	if n := len(h.groups); n > 0 {
+		groups := slices.Clone(h.groups)
+		groups[n-1].attrs = append(slices.Clip(groups[n-1].attrs), attrs...)
+		return &ContextHandler{next: h.next, keys: h.keys, groups: groups}
+	}
+	keys := make(map[string]struct{}, len(h.keys)+len(attrs))
+	maps.Copy(keys, h.keys)
+	for _, a := range attrs {
+		keys[a.Key] = struct{}{}
+	}
+	return &ContextHandler{next: h.next.WithAttrs(attrs), keys: keys}
+}
+
+func (h *ContextHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	groups := append(slices.Clip(h.groups), contextGroup{name: name})
+	return &ContextHandler{next: h.next, keys: h.keys, groups: groups}
+}
@@ -0,0 +1,92 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/goapt/logger/sloghttp"
+)
+
+func TestContextHandler(t *testing.T) {
+	var buf bytes.Buffer
+	l := slog.New(NewContextHandler(NewLogfmtHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})))
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+	ctx = sloghttp.NewContextAttributes(ctx, slog.String("user", "u1"), slog.Int("tenant", 7))
+
+	l.InfoContext(ctx, "plain")
+	l.With("user", "u2").InfoContext(ctx, "with")
+	l.WithGroup("g").With("k", 1).InfoContext(ctx, "group", "user", "u3")
+	l.InfoContext(context.Background(), "none")
+
+	lines := strings.Split(buf.String(), "\n")
+	ids := "trace_id=4bf92f3577b34da6a3ce929d0e0e4736 span_id=00f067aa0ba902b7"
+	assert.Equal(t, "level=INFO msg=plain "+ids+" tenant=7 user=u1", lines[0])
+	assert.Equal(t, "level=INFO msg=with user=u2 "+ids+" tenant=7", lines[1])
+	assert.Equal(t, "level=INFO msg=group g.k=1 g.user=u3 "+ids+" tenant=7 user=u1", lines[2])
+	assert.Equal(t, "level=INFO msg=none", lines[3])
+}
+
+func TestLogger_WithContextGroup(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := Open(&Config{Mode: ModeCustom, Writer: &buf, WithContext: true, Ring: &RingConfig{}})
+	require.NoError(t, err)
+
+	h := sloghttp.NewMiddleware(slog.New(slog.DiscardHandler), sloghttp.DefaultConfig)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := trace.ContextWithSpanContext(r.Context(), trace.SpanContextFromContext(testSpanContext()))
+		l.WithGroup("payment").With("amount", 3).WithGroup("card").InfoContext(ctx, "charged", "last4", "4242")
+		l.WithGroup("empty").InfoContext(ctx, "no attrs")
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(sloghttp.RequestIDHeaderKey, "req-1")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `"payment":{"amount":3,"card":{"last4":"4242"}},"request_id":"req-1","trace_id":"4bf92f3577b34da6a3ce929d0e0e4736","span_id":"00f067aa0ba902b7"}`)
+	assert.Contains(t, lines[1], `"msg":"no attrs","request_id":"req-1"`)
+
+	// the ring buffer finds them by request ID
+	assert.Len(t, l.Ring().Records(RingFilter{RequestID: "req-1"}), 2)
+}
+
+func TestLogger_WithContext(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&Config{Mode: ModeCustom, Writer: &buf, WithContext: true})
+
+	var requestID string
+	h := sloghttp.NewMiddleware(l, sloghttp.DefaultConfig)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID = sloghttp.GetRequestID(r)
+		sloghttp.AddContextAttributes(r.Context(), slog.String("user", "u1"))
+		l.InfoContext(r.Context(), "handling")
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `"msg":"handling","request_id":"`+requestID+`","user":"u1"}`)
+	// the access log already has them, they are not repeated
+	assert.Equal(t, 1, strings.Count(lines[1], `"request_id"`))
+	assert.Equal(t, 1, strings.Count(lines[1], `"user"`))
+}
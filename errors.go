@@ -0,0 +1,236 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// StackTracer is implemented by errors carrying the stack where they were
+// created, as program counters such as those returned by runtime.Callers.
+// Errors with a StackTrace method returning a slice of uintptr-based frames,
+// like those of github.com/pkg/errors, are supported as well.
+type StackTracer interface {
+	StackTrace() []uintptr
+}
+
+type ErrorsConfig struct {
+	StackLevel *slog.Level `json:"stack_level" yaml:"stack_level"` // add the stack of the log call to records at this level and above, default error
+	NoStack    bool        `json:"no_stack" yaml:"no_stack"`       // never add the stack of the log call
+	StackDepth int         `json:"stack_depth" yaml:"stack_depth"` // most frames of a stack, default 32
+	MaxCauses  int         `json:"max_causes" yaml:"max_causes"`   // most causes unwrapped from an error, default 16
+}
+
+// ErrorHandler replaces the error values of attrs with a group holding the
+// message, the concrete type, the causes unwrapped from errors.Join and %w
+// chains and, for StackTracer errors, the stack where the error was created.
+// Records at the stack level and above get a stack attr with the stack of
+// the log call, found on the goroutine calling Handle: behind a handler
+// handling records later on another goroutine, such as an AsyncHandler, or
+// for the records replayed by a BufferContext, they get none.
+type ErrorHandler struct {
+	next slog.Handler
+	conf ErrorsConfig
+}
+
+// errorInfo is an unwrapped cause of an error.
+type errorInfo struct {
+	Msg    string      `json:"msg"`
+	Type   string      `json:"type"`
+	Stack  []string    `json:"stack,omitempty"`
+	Causes []errorInfo `json:"causes,omitempty"` // only set for the errors of an errors.Join
+}
+
+// NewErrorHandler creates an ErrorHandler sending the enriched records to next.
+func NewErrorHandler(next slog.Handler, conf ErrorsConfig) *ErrorHandler {
+	if conf.StackLevel == nil {
+		level := slog.LevelError
+		conf.StackLevel = &level
+	}
+	if conf.StackDepth <= 0 {
+		conf.StackDepth = 32
+	}
+	if conf.MaxCauses <= 0 {
+		conf.MaxCauses = 16
+	}
+	return &ErrorHandler{next: next, conf: conf}
+}
+
+func (h *ErrorHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *ErrorHandler) Handle(ctx context.Context, r slog.Record) error {
+	nr := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		nr.AddAttrs(h.enrich(a))
+		return true
+	})
+	if !h.conf.NoStack && r.Level >= *h.conf.StackLevel {
+		if stack := h.callStack(r.PC); len(stack) > 0 {
+			nr.AddAttrs(slog.Any("stack", stack))
+		}
+	}
+	return h.next.Handle(ctx, nr)
+}
+
+func (h *ErrorHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	enriched := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		enriched[i] = h.enrich(a)
+	}
+	return &ErrorHandler{next: h.next.WithAttrs(enriched), conf: h.conf}
+}
+
+func (h *ErrorHandler) WithGroup(name string) slog.Handler {
+	return &ErrorHandler{next: h.next.WithGroup(name), conf: h.conf}
+}
+
+func (h *ErrorHandler) enrich(a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+	switch a.Value.Kind() {
+	case slog.KindGroup:
+		attrs := a.Value.Group()
+		enriched := make([]slog.Attr, len(attrs))
+		for i, ga := range attrs {
+			enriched[i] = h.enrich(ga)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(enriched...)}
+	case slog.KindAny:
+		err, ok := a.Value.Any().(error)
+		if !ok || err == nil {
+			return a
+		}
+		attrs := []slog.Attr{slog.String("msg", err.Error()), slog.String("type", errorType(err))}
+		if stack := h.errorStack(err); len(stack) > 0 {
+			attrs = append(attrs, slog.Any("stack", stack))
+		}
+		budget := h.conf.MaxCauses
+		if causes := h.causes(err, &budget); len(causes) > 0 {
+			attrs = append(attrs, slog.Any("causes", causes))
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(attrs...)}
+	}
+	return a
+}
+
+// causes returns the errors wrapped by err: the %w chain flattened, the
+// errors of an errors.Join each with their own causes. budget bounds their number.
+func (h *ErrorHandler) causes(err error, budget *int) []errorInfo {
+	var causes []errorInfo
+	for *budget > 0 {
+		switch u := err.(type) {
+		case interface{ Unwrap() error }:
+			err = u.Unwrap()
+			if err == nil {
+				return causes
+			}
+			*budget--
+			causes = append(causes, h.info(err))
+		case interface{ Unwrap() []error }:
+			for _, e := range u.Unwrap() {
+				if e == nil || *budget <= 0 {
+					continue
+				}
+				*budget--
+				info := h.info(e)
+				info.Causes = h.causes(e, budget)
+				causes = append(causes, info)
+			}
+			return causes
+		default:
+			return causes
+		}
+	}
+	return causes
+}
+
+func (h *ErrorHandler) info(err error) errorInfo {
+	return errorInfo{Msg: err.Error(), Type: errorType(err), Stack: h.errorStack(err)}
+}
+
+// errorStack returns the stack carried by err, if it is a StackTracer.
+func (h *ErrorHandler) errorStack(err error) []string {
+	if st, ok := err.(StackTracer); ok {
+		return h.frames(st.StackTrace())
+	}
+
+	// github.com/pkg/errors style: StackTrace() returns a slice of uintptr-based frames
+	m := reflect.ValueOf(err).MethodByName("StackTrace")
+	if !m.IsValid() || m.Type().NumIn() != 0 || m.Type().NumOut() != 1 {
+		return nil
+	}
+	out := m.Type().Out(0)
+	if out.Kind() != reflect.Slice || out.Elem().Kind() != reflect.Uintptr {
+		return nil
+	}
+	frames := m.Call(nil)[0]
+	pcs := make([]uintptr, frames.Len())
+	for i := range pcs {
+		// a frame holds the program counter + 1, as pkg/errors Frame.pc undoes
+		pcs[i] = uintptr(frames.Index(i).Uint()) - 1
+	}
+	return h.funcFrames(pcs)
+}
+
+// callStack returns the stack of the log call whose program counter is pc.
+func (h *ErrorHandler) callStack(pc uintptr) []string {
+	if pc == 0 {
+		return nil
+	}
+	pcs := make([]uintptr, 64)
+	pcs = pcs[:runtime.Callers(2, pcs)]
+	for i, p := range pcs {
+		if p == pc {
+			return h.frames(pcs[i:])
+		}
+	}
+	return nil
+}
+
+// frames formats the return program counters returned by runtime.Callers as
+// "function file:line".
+func (h *ErrorHandler) frames(pcs []uintptr) []string {
+	var stack []string
+	frames := runtime.CallersFrames(pcs)
+	for len(stack) < h.conf.StackDepth {
+		f, more := frames.Next()
+		if f.Function != "" && !strings.HasPrefix(f.Function, "runtime.") {
+			stack = append(stack, formatFrame(f.Function, f.File, f.Line))
+		}
+		if !more {
+			break
+		}
+	}
+	return stack
+}
+
+// funcFrames formats the program counters of the calls themselves as
+// "function file:line".
+func (h *ErrorHandler) funcFrames(pcs []uintptr) []string {
+	var stack []string
+	for _, pc := range pcs {
+		if len(stack) >= h.conf.StackDepth {
+			break
+		}
+		fn := runtime.FuncForPC(pc)
+		if fn == nil || strings.HasPrefix(fn.Name(), "runtime.") {
+			continue
+		}
+		file, line := fn.FileLine(pc)
+		stack = append(stack, formatFrame(fn.Name(), file, line))
+	}
+	return stack
+}
+
+func formatFrame(function, file string, line int) string {
+	return function + " " + file + ":" + strconv.Itoa(line)
+}
+
+func errorType(err error) string {
+	return fmt.Sprintf("%T", err)
+}
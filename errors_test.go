@@ -0,0 +1,168 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type tracedError struct {
+	msg string
+	pcs []uintptr
+}
+
+func newTracedError(msg string) *tracedError {
+	pcs := make([]uintptr, 32)
+	return &tracedError{msg: msg, pcs: pcs[:runtime.Callers(2, pcs)]}
+}
+
+func (e *tracedError) Error() string         { return e.msg }
+func (e *tracedError) StackTrace() []uintptr { return e.pcs }
+
+// frame and pkgError mimic the stack traces of github.com/pkg/errors.
+type frame uintptr
+
+type pkgError struct {
+	msg   string
+	stack []frame
+}
+
+func (e *pkgError) Error() string { return e.msg }
+
+func (e *pkgError) StackTrace() []frame { return e.stack }
+
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	var lines []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var m map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &m), line)
+		lines = append(lines, m)
+	}
+	return lines
+}
+
+func TestErrorHandler_Causes(t *testing.T) {
+	var buf bytes.Buffer
+	l := slog.New(NewErrorHandler(slog.NewJSONHandler(&buf, nil), ErrorsConfig{}))
+
+	_, statErr := os.Stat("/does/not/exist")
+	wrapped := fmt.Errorf("load config: %w", statErr)
+	joined := errors.Join(wrapped, errors.New("no fallback"))
+	l.Warn("failed", "error", joined, slog.Group("req", "err", wrapped), "count", 1)
+
+	m := decodeLines(t, &buf)[0]
+	assert.NotContains(t, m, "stack")
+	assert.EqualValues(t, 1, m["count"])
+
+	e := m["error"].(map[string]any)
+	assert.Equal(t, joined.Error(), e["msg"])
+	assert.Equal(t, "*errors.joinError", e["type"])
+	causes := e["causes"].([]any)
+	require.Len(t, causes, 2)
+
+	first := causes[0].(map[string]any)
+	assert.Equal(t, wrapped.Error(), first["msg"])
+	assert.Equal(t, "*fmt.wrapError", first["type"])
+	inner := first["causes"].([]any)
+	require.Len(t, inner, 2)
+	assert.Equal(t, "*fs.PathError", inner[0].(map[string]any)["type"])
+	assert.Equal(t, "syscall.Errno", inner[1].(map[string]any)["type"])
+	assert.Equal(t, map[string]any{"msg": "no fallback", "type": "*errors.errorString"}, causes[1])
+
+	req := m["req"].(map[string]any)["err"].(map[string]any)
+	assert.Equal(t, "*fmt.wrapError", req["type"])
+	assert.Len(t, req["causes"], 2)
+	assert.ErrorIs(t, wrapped, fs.ErrNotExist)
+}
+
+func TestErrorHandler_MaxCauses(t *testing.T) {
+	var buf bytes.Buffer
+	l := slog.New(NewErrorHandler(slog.NewJSONHandler(&buf, nil), ErrorsConfig{MaxCauses: 2}))
+
+	err := errors.New("root")
+	for i := range 5 {
+		err = fmt.Errorf("wrap %d: %w", i, err)
+	}
+	l.Info("deep", "error", err)
+
+	causes := decodeLines(t, &buf)[0]["error"].(map[string]any)["causes"].([]any)
+	require.Len(t, causes, 2)
+	assert.Equal(t, "wrap 3: wrap 2: wrap 1: wrap 0: root", causes[0].(map[string]any)["msg"])
+}
+
+func TestErrorHandler_Stack(t *testing.T) {
+	var buf bytes.Buffer
+	l := slog.New(NewErrorHandler(slog.NewJSONHandler(&buf, nil), ErrorsConfig{}))
+
+	l.Error("traced", "error", newTracedError("boom"))
+	l.Info("not traced")
+	pc, line := tracedPC()
+	l.With("error", &pkgError{msg: "pkg", stack: []frame{frame(pc)}}).Warn("pkg")
+
+	lines := decodeLines(t, &buf)
+	require.Len(t, lines, 3)
+
+	stack := lines[0]["stack"].([]any)
+	require.NotEmpty(t, stack)
+	assert.Contains(t, stack[0], "logger.TestErrorHandler_Stack ")
+	assert.Contains(t, stack[0], "errors_test.go:")
+	errStack := lines[0]["error"].(map[string]any)["stack"].([]any)
+	assert.Contains(t, errStack[0], "logger.TestErrorHandler_Stack ")
+
+	assert.NotContains(t, lines[1], "stack")
+
+	assert.NotContains(t, lines[2], "stack")
+	pkgStack := lines[2]["error"].(map[string]any)["stack"].([]any)
+	require.Len(t, pkgStack, 1)
+	assert.Contains(t, pkgStack[0], "logger.tracedPC ")
+	assert.True(t, strings.HasSuffix(pkgStack[0].(string), fmt.Sprintf("errors_test.go:%d", line)), pkgStack[0])
+}
+
+// tracedPC returns the pc+1 of a call, as held by a pkg/errors frame, and its line.
+func tracedPC() (uintptr, int) {
+	pcs := make([]uintptr, 1)
+	runtime.Callers(1, pcs)
+	_, _, line, _ := runtime.Caller(0)
+	return pcs[0], line - 1
+}
+
+func TestErrorHandler_StackLevel(t *testing.T) {
+	var buf bytes.Buffer
+	warn := slog.LevelWarn
+	l := slog.New(NewErrorHandler(slog.NewJSONHandler(&buf, nil), ErrorsConfig{StackLevel: &warn, StackDepth: 1}))
+	l.Warn("warned")
+	assert.Len(t, decodeLines(t, &buf)[0]["stack"], 1)
+
+	buf.Reset()
+	l = slog.New(NewErrorHandler(slog.NewJSONHandler(&buf, nil), ErrorsConfig{NoStack: true}))
+	l.Error("failed")
+	assert.NotContains(t, decodeLines(t, &buf)[0], "stack")
+}
+
+func TestConfig_Errors(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := Open(&Config{Mode: ModeCustom, Writer: &buf, Errors: &ErrorsConfig{}, Redact: &RedactConfig{}})
+	require.NoError(t, err)
+	defer l.Close()
+
+	l.Error("failed", "error", fmt.Errorf("notify alice@example.com: %w", errors.New("timeout")))
+
+	m := decodeLines(t, &buf)[0]
+	e := m["error"].(map[string]any)
+	assert.Equal(t, "notify [REDACTED]: timeout", e["msg"])
+	assert.Equal(t, "timeout", e["causes"].([]any)[0].(map[string]any)["msg"])
+	assert.Contains(t, m["stack"].([]any)[0], "logger.TestConfig_Errors ")
+
+	err = (&Config{Errors: &ErrorsConfig{StackDepth: -1}}).Validate()
+	assert.ErrorContains(t, err, "errors stack_depth and max_causes cannot be negative")
+}
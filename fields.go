@@ -0,0 +1,86 @@
+package logger
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// DefaultTimeFormat is the layout of times when Config.TimeFormat is empty.
+const DefaultTimeFormat = "2006-01-02 15:04:05.000"
+
+// Config.TimeFormat values besides Go layouts.
+const (
+	TimeRFC3339     = "rfc3339"
+	TimeRFC3339Nano = "rfc3339nano"
+	TimeUnix        = "unix"      // seconds since the epoch, as a number
+	TimeUnixMilli   = "unixmilli" // milliseconds since the epoch, as a number
+	TimeUnixMicro   = "unixmicro" // microseconds since the epoch, as a number
+	TimeUnixNano    = "unixnano"  // nanoseconds since the epoch, as a number
+)
+
+// FieldKeys renames the built-in keys of records, an empty key keeps slog's.
+// Top-level attrs with the same key as a built-in are renamed too.
+type FieldKeys struct {
+	Time    string `json:"time" yaml:"time"`       // default time, e.g. @timestamp
+	Level   string `json:"level" yaml:"level"`     // default level, e.g. severity
+	Message string `json:"message" yaml:"message"` // default msg, e.g. message
+	Source  string `json:"source" yaml:"source"`   // default source
+}
+
+// replaceAttr returns the ReplaceAttr of the handlers built from c, it formats
+// every time attr, nested ones included, and renames the built-in keys.
+func (c *Config) replaceAttr() (func(groups []string, a slog.Attr) slog.Attr, error) {
+	loc := time.Local
+	if c.TimeZone != "" {
+		var err error
+		if loc, err = time.LoadLocation(c.TimeZone); err != nil {
+			return nil, fmt.Errorf("invalid time_zone: %w", err)
+		}
+	}
+	format := timeFormatter(c.TimeFormat)
+
+	keys := make(map[string]string)
+	for key, name := range map[string]string{
+		slog.TimeKey:    c.Keys.Time,
+		slog.LevelKey:   c.Keys.Level,
+		slog.MessageKey: c.Keys.Message,
+		slog.SourceKey:  c.Keys.Source,
+	} {
+		if name != "" && name != key {
+			keys[key] = name
+		}
+	}
+
+	return func(groups []string, a slog.Attr) slog.Attr {
+		if a.Value.Kind() == slog.KindTime {
+			a.Value = format(a.Value.Time().In(loc))
+		}
+		if len(groups) == 0 {
+			if name, ok := keys[a.Key]; ok {
+				a.Key = name
+			}
+		}
+		return a
+	}, nil
+}
+
+func timeFormatter(format string) func(t time.Time) slog.Value {
+	switch format {
+	case "":
+		format = DefaultTimeFormat
+	case TimeRFC3339:
+		format = time.RFC3339
+	case TimeRFC3339Nano:
+		format = time.RFC3339Nano
+	case TimeUnix:
+		return func(t time.Time) slog.Value { return slog.Int64Value(t.Unix()) }
+	case TimeUnixMilli:
+		return func(t time.Time) slog.Value { return slog.Int64Value(t.UnixMilli()) }
+	case TimeUnixMicro:
+		return func(t time.Time) slog.Value { return slog.Int64Value(t.UnixMicro()) }
+	case TimeUnixNano:
+		return func(t time.Time) slog.Value { return slog.Int64Value(t.UnixNano()) }
+	}
+	return func(t time.Time) slog.Value { return slog.StringValue(t.Format(format)) }
+}
@@ -0,0 +1,60 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_TimeFormatAndKeys(t *testing.T) {
+	at := time.Date(2024, 1, 2, 15, 4, 5, 123456789, time.UTC)
+	for _, tt := range []struct {
+		format string
+		want   any
+	}{
+		{"", "2024-01-02 23:04:05.123"},
+		{TimeRFC3339, "2024-01-02T23:04:05+08:00"},
+		{TimeRFC3339Nano, "2024-01-02T23:04:05.123456789+08:00"},
+		{TimeUnix, float64(1704207845)},
+		{TimeUnixMilli, float64(1704207845123)},
+		{"02/01/2006 15:04", "02/01/2024 23:04"},
+	} {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := New(&Config{
+				Mode:       ModeCustom,
+				Writer:     &buf,
+				Detail:     true,
+				TimeFormat: tt.format,
+				TimeZone:   "Asia/Shanghai",
+				Keys:       FieldKeys{Time: "@timestamp", Level: "severity", Message: "message", Source: "caller"},
+			})
+			l.Info("hello", slog.Group("job", slog.Time("started", at)))
+
+			var got map[string]any
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+			assert.Equal(t, "INFO", got["severity"])
+			assert.Equal(t, "hello", got["message"])
+			assert.Contains(t, got, "@timestamp")
+			assert.Contains(t, got, "caller")
+			assert.NotContains(t, got, "time")
+			assert.Equal(t, tt.want, got["job"].(map[string]any)["started"])
+		})
+	}
+}
+
+func TestConfig_TimeZoneUTC(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&Config{Mode: ModeCustom, Writer: &buf, Format: FormatLogfmt, TimeFormat: TimeRFC3339Nano, TimeZone: "UTC"})
+	l.Info("hello", "at", time.Date(2024, 1, 2, 15, 4, 5, 0, time.FixedZone("CST", 8*3600)))
+	assert.Contains(t, buf.String(), "at=2024-01-02T07:04:05Z\n")
+	assert.Regexp(t, `^time=\S+Z level=INFO`, buf.String())
+
+	err := (&Config{TimeZone: "Mars/Olympus"}).Validate()
+	assert.ErrorContains(t, err, "invalid time_zone")
+}
@@ -0,0 +1,229 @@
+package logger
+
+import (
+	"context"
+	"encoding"
+	"fmt"
+	"io"
+	"log/slog"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+type Format string
+
+const (
+	FormatJSON   Format = "json"
+	FormatText   Format = "text"
+	FormatLogfmt Format = "logfmt"
+)
+
+// newFormatHandler builds the handler that encodes records in the given format.
+func newFormatHandler(w io.Writer, format Format, opts *slog.HandlerOptions) slog.Handler {
+	switch format {
+	case FormatText:
+		return slog.NewTextHandler(w, opts)
+	case FormatLogfmt:
+		return NewLogfmtHandler(w, opts)
+	case FormatConsole:
+		return NewConsoleHandler(w, &ConsoleOptions{HandlerOptions: *opts, Color: colorEnabled(w)})
+	default:
+		return slog.NewJSONHandler(w, opts)
+	}
+}
+
+// LogfmtHandler is a slog.Handler that writes records as logfmt lines,
+// e.g. time="2024-01-02 15:04:05.000" level=INFO msg=hello request.method=GET
+//
+// Nested groups are flattened into dotted keys, values are quoted only when
+// they contain spaces, '=', '"' or non-printable characters.
+type LogfmtHandler struct {
+	opts   slog.HandlerOptions
+	pre    []byte   // attrs added by WithAttrs, already encoded
+	prefix string   // dotted prefix of the open groups
+	groups []string // open groups, passed to ReplaceAttr
+	mu     *sync.Mutex
+	w      io.Writer
+}
+
+// NewLogfmtHandler creates a LogfmtHandler that writes to w, using the given options.
+// If opts is nil, the default options are used.
+func NewLogfmtHandler(w io.Writer, opts *slog.HandlerOptions) *LogfmtHandler {
+	if opts == nil {
+		opts = &slog.HandlerOptions{}
+	}
+	return &LogfmtHandler{opts: *opts, mu: &sync.Mutex{}, w: w}
+}
+
+func (h *LogfmtHandler) Enabled(_ context.Context, level slog.Level) bool {
+	minLevel := slog.LevelInfo
+	if h.opts.Level != nil {
+		minLevel = h.opts.Level.Level()
+	}
+	return level >= minLevel
+}
+
+func (h *LogfmtHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	h2 := *h
+	h2.pre = append([]byte(nil), h.pre...)
+	for _, a := range attrs {
+		h2.pre = h2.appendAttr(h2.pre, h.prefix, h.groups, a)
+	}
+	return &h2
+}
+
+func (h *LogfmtHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.prefix = h.prefix + name + "."
+	h2.groups = append(h.groups[:len(h.groups):len(h.groups)], name)
+	return &h2
+}
+
+func (h *LogfmtHandler) Handle(_ context.Context, r slog.Record) error {
+	buf := make([]byte, 0, 1024)
+
+	if !r.Time.IsZero() {
+		buf = h.appendAttr(buf, "", nil, slog.Time(slog.TimeKey, r.Time))
+	}
+	buf = h.appendAttr(buf, "", nil, slog.Any(slog.LevelKey, r.Level))
+	buf = h.appendAttr(buf, "", nil, slog.String(slog.MessageKey, r.Message))
+	if h.opts.AddSource && r.PC != 0 {
+		buf = h.appendAttr(buf, "", nil, slog.Any(slog.SourceKey, recordSource(r)))
+	}
+
+	if len(h.pre) > 0 {
+		if len(buf) > 0 {
+			buf = append(buf, ' ')
+		}
+		buf = append(buf, h.pre...)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		buf = h.appendAttr(buf, h.prefix, h.groups, a)
+		return true
+	})
+	buf = append(buf, '\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.w.Write(buf)
+	return err
+}
+
+func (h *LogfmtHandler) appendAttr(buf []byte, prefix string, groups []string, a slog.Attr) []byte {
+	a.Value = a.Value.Resolve()
+	if h.opts.ReplaceAttr != nil && a.Value.Kind() != slog.KindGroup {
+		a = h.opts.ReplaceAttr(groups, a)
+		a.Value = a.Value.Resolve()
+	}
+	if a.Equal(slog.Attr{}) {
+		return buf
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		attrs := a.Value.Group()
+		if len(attrs) == 0 {
+			return buf
+		}
+		if a.Key != "" {
+			prefix += a.Key + "."
+			groups = append(groups[:len(groups):len(groups)], a.Key)
+		}
+		for _, ga := range attrs {
+			buf = h.appendAttr(buf, prefix, groups, ga)
+		}
+		return buf
+	}
+
+	if len(buf) > 0 {
+		buf = append(buf, ' ')
+	}
+	buf = appendLogfmtKey(buf, prefix+a.Key)
+	buf = append(buf, '=')
+	return appendLogfmtValue(buf, a.Value)
+}
+
+func appendLogfmtKey(buf []byte, key string) []byte {
+	if key == "" {
+		return append(buf, '_')
+	}
+	for _, c := range key {
+		if c <= ' ' || c == '=' || c == '"' || c == utf8.RuneError {
+			c = '_'
+		}
+		buf = utf8.AppendRune(buf, c)
+	}
+	return buf
+}
+
+func appendLogfmtValue(buf []byte, v slog.Value) []byte {
+	switch v.Kind() {
+	case slog.KindString:
+		return appendLogfmtString(buf, v.String())
+	case slog.KindInt64:
+		return strconv.AppendInt(buf, v.Int64(), 10)
+	case slog.KindUint64:
+		return strconv.AppendUint(buf, v.Uint64(), 10)
+	case slog.KindFloat64:
+		return strconv.AppendFloat(buf, v.Float64(), 'g', -1, 64)
+	case slog.KindBool:
+		return strconv.AppendBool(buf, v.Bool())
+	case slog.KindDuration:
+		return append(buf, v.Duration().String()...)
+	case slog.KindTime:
+		return append(buf, v.Time().Format(time.RFC3339Nano)...)
+	}
+
+	switch x := v.Any().(type) {
+	case *slog.Source:
+		return appendLogfmtString(buf, fmt.Sprintf("%s:%d", x.File, x.Line))
+	case slog.Level:
+		return append(buf, x.String()...)
+	case error:
+		return appendLogfmtString(buf, x.Error())
+	case encoding.TextMarshaler:
+		data, err := x.MarshalText()
+		if err != nil {
+			return appendLogfmtString(buf, "!ERROR:"+err.Error())
+		}
+		return appendLogfmtString(buf, string(data))
+	case []byte:
+		return appendLogfmtString(buf, string(x))
+	default:
+		return appendLogfmtString(buf, fmt.Sprintf("%+v", x))
+	}
+}
+
+func appendLogfmtString(buf []byte, s string) []byte {
+	if !needsQuote(s) {
+		return append(buf, s...)
+	}
+	return strconv.AppendQuote(buf, s)
+}
+
+func needsQuote(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, c := range s {
+		if c <= ' ' || c == '=' || c == '"' || c == '\\' || c == utf8.RuneError || !strconv.IsPrint(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// recordSource returns the source location of the record's log call.
+func recordSource(r slog.Record) *slog.Source {
+	fs := runtime.CallersFrames([]uintptr{r.PC})
+	f, _ := fs.Next()
+	return &slog.Source{Function: f.Function, File: f.File, Line: f.Line}
+}
@@ -0,0 +1,67 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogger_FormatText(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&Config{Mode: ModeCustom, Format: FormatText, Writer: &buf})
+
+	l.Info("hello world", slog.String("foo", "bar"))
+
+	got := buf.String()
+	assert.Contains(t, got, `level=INFO msg="hello world" foo=bar`)
+	assert.True(t, strings.HasPrefix(got, `time="`))
+}
+
+func TestLogger_FormatLogfmt(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&Config{Mode: ModeCustom, Format: FormatLogfmt, Writer: &buf, Detail: true})
+
+	l.With("app", "demo").WithGroup("request").Info("hello world",
+		slog.String("method", "GET"),
+		slog.String("path", "/a b"),
+		slog.Group("header", slog.String("accept", `text/"html"`)),
+		slog.Duration("latency", 1500*time.Millisecond),
+		slog.Any("error", errors.New("boom")),
+		slog.Group("empty"),
+	)
+
+	line := strings.TrimSuffix(buf.String(), "\n")
+	assert.NotContains(t, line, "\n")
+
+	ts := line[len(`time="`) : len(`time="`)+len("2006-01-02 15:04:05.000")]
+	_, err := time.Parse("2006-01-02 15:04:05.000", ts)
+	assert.NoError(t, err)
+
+	assert.Contains(t, line, ` level=INFO msg="hello world" source=`)
+	assert.Contains(t, line, `format_test.go:`)
+	assert.True(t, strings.HasSuffix(line, ` app=demo request.method=GET request.path="/a b" request.header.accept="text/\"html\"" request.latency=1.5s request.error=boom`), line)
+}
+
+func TestLogfmtHandler_ReplaceAttr(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewLogfmtHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			if a.Key == "secret" {
+				return slog.String(a.Key, "***")
+			}
+			return a
+		},
+	})
+
+	slog.New(h).Warn("x", "secret", "p@ss", "empty", "", "n", 3, "ok", true)
+
+	assert.Equal(t, "level=WARN msg=x secret=*** empty=\"\" n=3 ok=true\n", buf.String())
+}
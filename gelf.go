@@ -0,0 +1,243 @@
+package logger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"math"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FormatGELF writes records as Graylog Extended Log Format 1.1 messages.
+const FormatGELF Format = "gelf"
+
+// GELF compressions of UDP messages.
+const (
+	GELFCompressNone = "none"
+	GELFCompressGzip = "gzip"
+	GELFCompressZlib = "zlib"
+)
+
+// gelfMaxChunks is the most chunks of a GELF UDP message.
+const gelfMaxChunks = 128
+
+type GELFConfig struct {
+	Host        string `json:"host" yaml:"host"`               // default os.Hostname()
+	Compression string `json:"compression" yaml:"compression"` // none, gzip or zlib, only used for udp, default none
+	ChunkSize   int    `json:"chunk_size" yaml:"chunk_size"`   // most bytes of a UDP datagram, default 1420, 8154 suits LANs
+}
+
+// GELFHandler writes records as GELF 1.1 JSON messages, one per line.
+// The first line of the message is the short_message, a multiline message
+// is also the full_message. Levels map to syslog severities. Attrs become
+// additional fields prefixed with an underscore, group keys joined with
+// underscores, e.g. request.method to _request_method.
+//
+// In network mode the messages are null-byte delimited over TCP and, over
+// UDP, compressed according to GELFConfig and chunked when they exceed a
+// datagram.
+type GELFHandler struct {
+	w      io.Writer
+	mu     *sync.Mutex
+	host   string
+	opts   slog.HandlerOptions
+	attrs  []slog.Attr // flattened attrs of the logger
+	prefix string      // dotted prefix of the open groups
+}
+
+// NewGELFHandler creates a GELFHandler writing to w.
+func NewGELFHandler(w io.Writer, conf GELFConfig, opts *slog.HandlerOptions) *GELFHandler {
+	h := &GELFHandler{w: w, mu: &sync.Mutex{}, host: conf.Host}
+	if h.host == "" {
+		h.host, _ = os.Hostname()
+	}
+	if opts != nil {
+		h.opts = *opts
+	}
+	return h
+}
+
+func (h *GELFHandler) Enabled(_ context.Context, level slog.Level) bool {
+	min := slog.LevelInfo
+	if h.opts.Level != nil {
+		min = h.opts.Level.Level()
+	}
+	return level >= min
+}
+
+func (h *GELFHandler) Handle(_ context.Context, r slog.Record) error {
+	short, _, multiline := strings.Cut(r.Message, "\n")
+	if short == "" {
+		// short_message is required
+		short = "-"
+	}
+
+	buf := make([]byte, 0, 512)
+	buf = append(buf, `{"version":"1.1","host":`...)
+	buf = appendJSONString(buf, h.host)
+	buf = append(buf, `,"short_message":`...)
+	buf = appendJSONString(buf, short)
+	if multiline {
+		buf = append(buf, `,"full_message":`...)
+		buf = appendJSONString(buf, r.Message)
+	}
+	if !r.Time.IsZero() {
+		buf = append(buf, `,"timestamp":`...)
+		buf = strconv.AppendFloat(buf, float64(r.Time.UnixMilli())/1000, 'f', 3, 64)
+	}
+	buf = append(buf, `,"level":`...)
+	buf = strconv.AppendInt(buf, int64(syslogSeverity(r.Level)), 10)
+
+	var attrs []slog.Attr
+	if h.opts.AddSource && r.PC != 0 {
+		src := recordSource(r)
+		attrs = append(attrs, slog.String("file", src.File), slog.Int("line", src.Line), slog.String("function", src.Function))
+	}
+	attrs = append(attrs, h.attrs...)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = appendFlatAttr(attrs, h.prefix, a)
+		return true
+	})
+	for _, a := range attrs {
+		if h.opts.ReplaceAttr != nil {
+			if a = h.opts.ReplaceAttr(nil, a); a.Key == "" {
+				continue
+			}
+		}
+		buf = append(buf, ',')
+		buf = appendJSONString(buf, gelfFieldName(a.Key))
+		buf = append(buf, ':')
+		buf = appendGELFValue(buf, a.Value)
+	}
+	buf = append(buf, '}', '\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.w.Write(buf)
+	return err
+}
+
+func (h *GELFHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.attrs = slices.Clip(h.attrs)
+	for _, a := range attrs {
+		h2.attrs = appendFlatAttr(h2.attrs, h.prefix, a)
+	}
+	return &h2
+}
+
+func (h *GELFHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.prefix = h.prefix + name + "."
+	return &h2
+}
+
+// gelfFieldName returns the additional field name of a dotted attr key:
+// underscore prefixed, with characters other than letters, digits, dashes
+// and underscores replaced. The reserved _id is renamed __id.
+func gelfFieldName(key string) string {
+	name := []byte("_" + key)
+	for i, c := range name {
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') && c != '-' {
+			name[i] = '_'
+		}
+	}
+	if string(name) == "_id" {
+		return "__id"
+	}
+	return string(name)
+}
+
+// appendGELFValue appends v as a JSON number or string, the only types of
+// GELF fields.
+func appendGELFValue(buf []byte, v slog.Value) []byte {
+	switch v.Kind() {
+	case slog.KindInt64:
+		return strconv.AppendInt(buf, v.Int64(), 10)
+	case slog.KindUint64:
+		return strconv.AppendUint(buf, v.Uint64(), 10)
+	case slog.KindFloat64:
+		if f := v.Float64(); !math.IsInf(f, 0) && !math.IsNaN(f) {
+			return strconv.AppendFloat(buf, f, 'g', -1, 64)
+		}
+	case slog.KindTime:
+		return appendJSONString(buf, v.Time().Format(time.RFC3339Nano))
+	}
+	return appendJSONString(buf, v.String())
+}
+
+func appendJSONString(buf []byte, s string) []byte {
+	b, _ := json.Marshal(s)
+	return append(buf, b...)
+}
+
+// framing returns the framing of GELF messages sent over network: null-byte
+// delimited on streams, compressed and chunked datagrams otherwise.
+func (c *GELFConfig) framing(network string) netFraming {
+	if !isDatagram(network) {
+		return netFraming{frame: func(dst, rec []byte) []byte { return append(append(dst, rec...), 0) }}
+	}
+	size := c.ChunkSize
+	if size <= 12 {
+		size = 1420
+	}
+	compression := c.Compression
+	return netFraming{datagrams: func(rec []byte) [][]byte {
+		return gelfChunks(gelfCompress(rec, compression), size)
+	}}
+}
+
+func gelfCompress(msg []byte, compression string) []byte {
+	var buf bytes.Buffer
+	var zw io.WriteCloser
+	switch compression {
+	case GELFCompressGzip:
+		zw = gzip.NewWriter(&buf)
+	case GELFCompressZlib:
+		zw = zlib.NewWriter(&buf)
+	default:
+		return msg
+	}
+	_, _ = zw.Write(msg)
+	_ = zw.Close()
+	return buf.Bytes()
+}
+
+// gelfChunks splits msg into GELF chunks of at most size bytes, none when it
+// needs more than 128 chunks.
+//
+//	0x1e 0x0f, 8 bytes message ID, sequence number, sequence count, data
+func gelfChunks(msg []byte, size int) [][]byte {
+	if len(msg) <= size {
+		return [][]byte{msg}
+	}
+	data := size - 12
+	n := (len(msg) + data - 1) / data
+	if n > gelfMaxChunks {
+		return nil
+	}
+	id := rand.Uint64()
+	chunks := make([][]byte, n)
+	for i := range chunks {
+		chunk := make([]byte, 12, size)
+		chunk[0], chunk[1] = 0x1e, 0x0f
+		binary.BigEndian.PutUint64(chunk[2:], id)
+		chunk[10], chunk[11] = byte(i), byte(n)
+		chunks[i] = append(chunk, msg[i*data:min((i+1)*data, len(msg))]...)
+	}
+	return chunks
+}
@@ -0,0 +1,178 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGELFHandler(t *testing.T) {
+	var buf bytes.Buffer
+	l := slog.New(NewGELFHandler(&buf, GELFConfig{Host: "web-1"}, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	l.With("id", 7).Warn("request failed\nretrying",
+		slog.Group("request", "method", "GET", "path", "/orders"),
+		slog.Group("response", "status", 502, "latency", 1.5),
+		"ok", false, "user name", "bob",
+	)
+	l.WithGroup("job").Debug("", "attempt", uint64(2))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var m map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &m))
+	assert.InDelta(t, float64(time.Now().Unix()), m["timestamp"], 5)
+	delete(m, "timestamp")
+	assert.Equal(t, map[string]any{
+		"version":           "1.1",
+		"host":              "web-1",
+		"short_message":     "request failed",
+		"full_message":      "request failed\nretrying",
+		"level":             float64(4),
+		"__id":              float64(7),
+		"_request_method":   "GET",
+		"_request_path":     "/orders",
+		"_response_status":  float64(502),
+		"_response_latency": 1.5,
+		"_ok":               "false",
+		"_user_name":        "bob",
+	}, m)
+
+	assert.Contains(t, lines[1], `"short_message":"-","timestamp":`)
+	assert.Contains(t, lines[1], `"level":7,"_job_attempt":2}`)
+}
+
+func TestGELFChunks(t *testing.T) {
+	msg := bytes.Repeat([]byte("0123456789"), 30)
+	assert.Equal(t, [][]byte{msg}, gelfChunks(msg, 300))
+
+	chunks := gelfChunks(msg, 112)
+	require.Len(t, chunks, 3)
+	var joined []byte
+	for i, c := range chunks {
+		assert.LessOrEqual(t, len(c), 112)
+		assert.Equal(t, []byte{0x1e, 0x0f}, c[:2])
+		assert.Equal(t, chunks[0][2:10], c[2:10], "message id")
+		assert.Equal(t, []byte{byte(i), 3}, c[10:12])
+		joined = append(joined, c[12:]...)
+	}
+	assert.Equal(t, msg, joined)
+
+	assert.Nil(t, gelfChunks(make([]byte, 129*100), 112))
+}
+
+func TestLogger_GELFUDP(t *testing.T) {
+	for _, compression := range []string{GELFCompressNone, GELFCompressGzip, GELFCompressZlib} {
+		t.Run(compression, func(t *testing.T) {
+			pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+			require.NoError(t, err)
+			defer pc.Close()
+
+			l, err := Open(&Config{
+				Mode:    ModeNetwork,
+				Format:  FormatGELF,
+				Network: &NetworkConfig{Network: "udp", Address: pc.LocalAddr().String()},
+				GELF:    &GELFConfig{Host: "web-1", Compression: compression, ChunkSize: 64},
+			})
+			require.NoError(t, err)
+			defer l.Close()
+			l.Info("hello", "payload", strings.Repeat("x", 200))
+			require.NoError(t, l.Sync())
+
+			// chunks arrive in order on the loopback
+			var msg []byte
+			buf := make([]byte, 1024)
+			for {
+				_ = pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+				n, _, err := pc.ReadFrom(buf)
+				require.NoError(t, err)
+				require.Equal(t, []byte{0x1e, 0x0f}, buf[:2])
+				msg = append(msg, buf[12:n]...)
+				if buf[10] == buf[11]-1 {
+					break
+				}
+			}
+
+			var r io.Reader = bytes.NewReader(msg)
+			switch compression {
+			case GELFCompressGzip:
+				r, err = gzip.NewReader(r)
+				require.NoError(t, err)
+			case GELFCompressZlib:
+				r, err = zlib.NewReader(r)
+				require.NoError(t, err)
+			}
+			var m map[string]any
+			require.NoError(t, json.NewDecoder(r).Decode(&m))
+			assert.Equal(t, "hello", m["short_message"])
+			assert.Equal(t, strings.Repeat("x", 200), m["_payload"])
+		})
+	}
+}
+
+func TestLogger_GELFTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		var msgs []string
+		for len(msgs) < 2 {
+			msg, err := r.ReadString(0)
+			if err != nil {
+				return
+			}
+			msgs = append(msgs, strings.TrimSuffix(msg, "\x00"))
+		}
+		received <- msgs
+	}()
+
+	l, err := Open(&Config{
+		Mode:    ModeNetwork,
+		Format:  FormatGELF,
+		Network: &NetworkConfig{Address: ln.Addr().String()},
+		GELF:    &GELFConfig{Host: "web-1"},
+	})
+	require.NoError(t, err)
+	defer l.Close()
+	l.Info("first")
+	l.Error("second")
+	require.NoError(t, l.Sync())
+
+	select {
+	case msgs := <-received:
+		assert.True(t, strings.HasPrefix(msgs[0], `{"version":"1.1","host":"web-1","short_message":"first",`), msgs[0])
+		assert.True(t, strings.HasSuffix(msgs[1], `"level":3}`), msgs[1])
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+	}
+}
+
+func TestConfig_ValidateGELF(t *testing.T) {
+	err := (&Config{Mode: ModeNetwork, Format: FormatGELF, Network: &NetworkConfig{Address: "x"}, GELF: &GELFConfig{Compression: "gzip", ChunkSize: 10}}).Validate()
+	assert.ErrorContains(t, err, "gelf compression gzip requires a udp network")
+	assert.ErrorContains(t, err, "gelf chunk_size must be larger than the 12 bytes chunk header, got 10")
+
+	err = (&Config{Format: FormatGELF, GELF: &GELFConfig{Compression: "lz4"}}).Validate()
+	assert.ErrorContains(t, err, `unknown gelf compression "lz4"`)
+
+	assert.NoError(t, (&Config{Mode: ModeNetwork, Format: FormatGELF, Network: &NetworkConfig{Network: "udp", Address: "x"}, GELF: &GELFConfig{Compression: "zlib"}}).Validate())
+}
@@ -0,0 +1,155 @@
+package logger
+
+import (
+	"context"
+	"encoding/binary"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// DefaultJournalSocket is the native protocol socket of systemd-journald.
+const DefaultJournalSocket = "/run/systemd/journal/socket"
+
+type JournalConfig struct {
+	Socket     string `json:"socket" yaml:"socket"`         // default /run/systemd/journal/socket
+	Identifier string `json:"identifier" yaml:"identifier"` // SYSLOG_IDENTIFIER, default the program name
+}
+
+// JournalHandler writes records to systemd-journald with its native protocol.
+// The message goes to MESSAGE, the level to PRIORITY as a syslog severity,
+// the source of Detail to CODE_FILE, CODE_LINE and CODE_FUNC, and attrs to
+// uppercase fields with group keys joined by underscores, e.g. request_id to
+// REQUEST_ID and request.method to REQUEST_METHOD. Attrs named after a field
+// of the journal, such as message, are prefixed with X_. Entries too large
+// for a datagram are passed in a sealed memfd. It is only available on Linux.
+type JournalHandler struct {
+	state  *journalState
+	attrs  []slog.Attr // flattened attrs of the logger
+	prefix string      // dotted prefix of the open groups
+}
+
+type journalState struct {
+	conf JournalConfig
+	opts slog.HandlerOptions
+	conn *journalConn
+}
+
+// NewJournalHandler creates a JournalHandler, Close it to close the socket.
+func NewJournalHandler(conf JournalConfig, opts *slog.HandlerOptions) (*JournalHandler, error) {
+	if conf.Socket == "" {
+		conf.Socket = DefaultJournalSocket
+	}
+	if conf.Identifier == "" {
+		conf.Identifier = filepath.Base(os.Args[0])
+	}
+	conn, err := dialJournal(conf.Socket)
+	if err != nil {
+		return nil, err
+	}
+	s := &journalState{conf: conf, conn: conn}
+	if opts != nil {
+		s.opts = *opts
+	}
+	return &JournalHandler{state: s}, nil
+}
+
+func (h *JournalHandler) Enabled(_ context.Context, level slog.Level) bool {
+	min := slog.LevelInfo
+	if h.state.opts.Level != nil {
+		min = h.state.opts.Level.Level()
+	}
+	return level >= min
+}
+
+func (h *JournalHandler) Handle(_ context.Context, r slog.Record) error {
+	s := h.state
+	buf := make([]byte, 0, 256)
+	buf = appendJournalField(buf, "MESSAGE", r.Message)
+	buf = appendJournalField(buf, "PRIORITY", strconv.Itoa(syslogSeverity(r.Level)))
+	buf = appendJournalField(buf, "SYSLOG_IDENTIFIER", s.conf.Identifier)
+	if s.opts.AddSource && r.PC != 0 {
+		src := recordSource(r)
+		buf = appendJournalField(buf, "CODE_FILE", src.File)
+		buf = appendJournalField(buf, "CODE_LINE", strconv.Itoa(src.Line))
+		buf = appendJournalField(buf, "CODE_FUNC", src.Function)
+	}
+
+	attrs := slices.Clip(h.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = appendFlatAttr(attrs, h.prefix, a)
+		return true
+	})
+	for _, a := range attrs {
+		if s.opts.ReplaceAttr != nil {
+			if a = s.opts.ReplaceAttr(nil, a); a.Key == "" {
+				continue
+			}
+		}
+		buf = appendJournalField(buf, journalFieldName(a.Key), a.Value.String())
+	}
+	return s.conn.send(buf)
+}
+
+func (h *JournalHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	flat := slices.Clip(h.attrs)
+	for _, a := range attrs {
+		flat = appendFlatAttr(flat, h.prefix, a)
+	}
+	return &JournalHandler{state: h.state, attrs: flat, prefix: h.prefix}
+}
+
+func (h *JournalHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &JournalHandler{state: h.state, attrs: h.attrs, prefix: h.prefix + name + "."}
+}
+
+// Close closes the socket.
+func (h *JournalHandler) Close() error { return h.state.conn.close() }
+
+// journalFields are the fields of the journal set by the handler or with a
+// meaning to journald and journalctl, see systemd.journal-fields(7).
+var journalFields = map[string]bool{
+	"MESSAGE": true, "MESSAGE_ID": true, "PRIORITY": true, "CODE_FILE": true, "CODE_LINE": true,
+	"CODE_FUNC": true, "ERRNO": true, "INVOCATION_ID": true, "USER_INVOCATION_ID": true,
+	"SYSLOG_FACILITY": true, "SYSLOG_IDENTIFIER": true, "SYSLOG_PID": true, "SYSLOG_TIMESTAMP": true,
+	"SYSLOG_RAW": true, "DOCUMENTATION": true, "TID": true, "UNIT": true, "USER_UNIT": true,
+}
+
+// journalFieldName maps an attr key to a journal field name: uppercase
+// letters, digits and underscores, not starting with an underscore or a
+// digit nor naming a field of the journal, at most 64 bytes.
+func journalFieldName(key string) string {
+	name := []byte(strings.ToUpper(key))
+	for i, c := range name {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			name[i] = '_'
+		}
+	}
+	// a leading underscore marks the trusted fields set by journald
+	name = []byte(strings.TrimLeft(string(name), "_"))
+	if len(name) == 0 || name[0] >= '0' && name[0] <= '9' || journalFields[string(name)] {
+		name = append([]byte("X_"), name...)
+	}
+	return string(name[:min(len(name), 64)])
+}
+
+// appendJournalField appends NAME=value, or for values with a newline the
+// binary-safe NAME, the 64 bits little endian length and the value.
+func appendJournalField(buf []byte, name, value string) []byte {
+	buf = append(buf, name...)
+	if !strings.Contains(value, "\n") {
+		buf = append(buf, '=')
+		buf = append(buf, value...)
+		return append(buf, '\n')
+	}
+	buf = append(buf, '\n')
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(len(value)))
+	buf = append(buf, value...)
+	return append(buf, '\n')
+}
@@ -0,0 +1,80 @@
+//go:build linux
+
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+type journalConn struct {
+	conn *net.UnixConn
+	addr *net.UnixAddr
+}
+
+func dialJournal(socket string) (*journalConn, error) {
+	if _, err := os.Stat(socket); err != nil {
+		return nil, fmt.Errorf("journal socket: %w", err)
+	}
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Net: "unixgram"})
+	if err != nil {
+		return nil, fmt.Errorf("open journal socket: %w", err)
+	}
+	return &journalConn{conn: conn, addr: &net.UnixAddr{Name: socket, Net: "unixgram"}}, nil
+}
+
+// send writes the entry as a datagram, or passes it in a sealed memfd when
+// it is too large for one.
+func (c *journalConn) send(entry []byte) error {
+	_, _, err := c.conn.WriteMsgUnix(entry, nil, c.addr)
+	if err == nil || !errors.Is(err, syscall.EMSGSIZE) && !errors.Is(err, syscall.ENOBUFS) {
+		return err
+	}
+
+	f, err := journalFile(entry)
+	if err != nil {
+		return fmt.Errorf("write large journal entry: %w", err)
+	}
+	defer f.Close()
+	_, _, err = c.conn.WriteMsgUnix(nil, syscall.UnixRights(int(f.Fd())), c.addr)
+	return err
+}
+
+// journalFile returns a sealed memfd holding entry, or an unlinked temporary
+// file when memfds are not available.
+func journalFile(entry []byte) (*os.File, error) {
+	if fd, err := unix.MemfdCreate("logger-journal", unix.MFD_CLOEXEC|unix.MFD_ALLOW_SEALING); err == nil {
+		f := os.NewFile(uintptr(fd), "memfd:logger-journal")
+		if _, err := f.Write(entry); err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+		seals := unix.F_SEAL_SEAL | unix.F_SEAL_SHRINK | unix.F_SEAL_GROW | unix.F_SEAL_WRITE
+		if _, err := unix.FcntlInt(uintptr(fd), unix.F_ADD_SEALS, seals); err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+		return f, nil
+	}
+
+	f, err := os.CreateTemp("/dev/shm", "logger-journal-")
+	if err != nil {
+		if f, err = os.CreateTemp("", "logger-journal-"); err != nil {
+			return nil, err
+		}
+	}
+	// journald only accepts files without links
+	_ = os.Remove(f.Name())
+	if _, err := f.Write(entry); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+func (c *journalConn) close() error { return c.conn.Close() }
@@ -0,0 +1,84 @@
+//go:build linux
+
+package logger
+
+import (
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readJournalEntry reads an entry sent to the journal socket, inline or in a memfd.
+func readJournalEntry(t *testing.T, conn *net.UnixConn) string {
+	t.Helper()
+	buf := make([]byte, 64*1024)
+	oob := make([]byte, 64)
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, oobn, _, _, err := conn.ReadMsgUnix(buf, oob)
+	require.NoError(t, err)
+	if oobn == 0 {
+		return string(buf[:n])
+	}
+
+	msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	fds, err := syscall.ParseUnixRights(&msgs[0])
+	require.NoError(t, err)
+	require.Len(t, fds, 1)
+	f := os.NewFile(uintptr(fds[0]), "journal entry")
+	defer f.Close()
+	_, err = f.Seek(0, io.SeekStart)
+	require.NoError(t, err)
+	data, err := io.ReadAll(f)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestJournalHandler(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "journal.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	l, err := Open(&Config{
+		Mode:    ModeJournal,
+		Level:   slog.LevelDebug,
+		Detail:  true,
+		Journal: &JournalConfig{Socket: socket, Identifier: "billing"},
+	})
+	require.NoError(t, err)
+	defer l.Close()
+
+	l.With("request_id", "r-1").WithGroup("request").Warn("slow\nrequest", "method", "GET")
+	entry := readJournalEntry(t, conn)
+	assert.True(t, strings.HasPrefix(entry, "MESSAGE\n\x0c\x00\x00\x00\x00\x00\x00\x00slow\nrequest\nPRIORITY=4\nSYSLOG_IDENTIFIER=billing\n"), entry)
+	assert.Contains(t, entry, "\nCODE_FILE=")
+	assert.Contains(t, entry, "journal_linux_test.go\nCODE_LINE=")
+	assert.Contains(t, entry, "\nCODE_FUNC=github.com/goapt/logger.TestJournalHandler\n")
+	assert.True(t, strings.HasSuffix(entry, "\nREQUEST_ID=r-1\nREQUEST_METHOD=GET\n"), entry)
+
+	l.Debug("debug")
+	assert.Contains(t, readJournalEntry(t, conn), "MESSAGE=debug\nPRIORITY=7\n")
+
+	// too large for a datagram, sent in a memfd
+	big := strings.Repeat("x", 4*1024*1024)
+	l.Error("big", "payload", big)
+	entry = readJournalEntry(t, conn)
+	assert.True(t, strings.HasPrefix(entry, "MESSAGE=big\nPRIORITY=3\n"))
+	assert.True(t, strings.HasSuffix(entry, "\nPAYLOAD="+big+"\n"))
+}
+
+func TestJournalHandler_NoSocket(t *testing.T) {
+	_, err := NewJournalHandler(JournalConfig{Socket: filepath.Join(t.TempDir(), "missing.sock")}, nil)
+	assert.ErrorContains(t, err, "journal socket")
+}
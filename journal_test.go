@@ -0,0 +1,33 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJournalFieldName(t *testing.T) {
+	for key, want := range map[string]string{
+		"request_id":     "REQUEST_ID",
+		"request.method": "REQUEST_METHOD",
+		"user-agent":     "USER_AGENT",
+		"_hidden":        "HIDDEN",
+		"2fa":            "X_2FA",
+		"":               "X_",
+		"é":              "X_",
+		"message":        "X_MESSAGE",
+		"priority":       "X_PRIORITY",
+		"code.file":      "X_CODE_FILE",
+		"message_id":     "X_MESSAGE_ID",
+		"message_count":  "MESSAGE_COUNT",
+	} {
+		assert.Equal(t, want, journalFieldName(key), key)
+	}
+	assert.Len(t, journalFieldName(strings.Repeat("a", 100)), 64)
+}
+
+func TestAppendJournalField(t *testing.T) {
+	assert.Equal(t, "MESSAGE=hello\n", string(appendJournalField(nil, "MESSAGE", "hello")))
+	assert.Equal(t, "MESSAGE\n\x07\x00\x00\x00\x00\x00\x00\x00two\nrow\n", string(appendJournalField(nil, "MESSAGE", "two\nrow")))
+}
@@ -0,0 +1,222 @@
+package logger
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// LevelVar returns the level of Default(), see Logger.LevelVar. When Default()
+// was not built by New, changing the returned level has no effect.
+func LevelVar() *slog.LevelVar { return &defaultLevels().LevelVar }
+
+// LevelVar returns the level of l, initially Config.Level. Changes apply
+// immediately to l and the loggers derived from it, not to other loggers.
+func (l *Logger) LevelVar() *slog.LevelVar { return &l.levels.LevelVar }
+
+type levelRequest struct {
+	Logger string `json:"logger"` // pattern of the named loggers to change, empty for the level of the logger
+	Level  string `json:"level"`
+	TTL    int    `json:"ttl"` // minutes until the previous level is restored, 0 keeps the level
+}
+
+type levelResponse struct {
+	Logger  string                `json:"logger,omitempty"`
+	Level   string                `json:"level"`
+	Expires *time.Time            `json:"expires,omitempty"`
+	Levels  map[string]slog.Level `json:"levels,omitempty"`
+}
+
+type levelServer struct {
+	levels  func() *levels
+	unit    time.Duration // of ttl
+	mu      sync.Mutex
+	pending map[string]*pendingLevel // keyed by logger pattern, "" for the level of the logger
+}
+
+// pendingLevel is a change made with a ttl.
+type pendingLevel struct {
+	timer   *time.Timer
+	restore func()
+	expires time.Time
+}
+
+// LevelHandler returns an http.Handler to inspect and change the levels of
+// Default() at runtime, see Logger.LevelHandler.
+func LevelHandler() http.Handler { return newLevelServer(defaultLevels) }
+
+// LevelHandler returns an http.Handler to inspect and change the levels of l at runtime.
+//
+//	GET     reports the level and the named overrides: {"level":"INFO","levels":{"billing.*":"DEBUG"}}
+//	        with ?logger=name, reports the level in effect for that named logger
+//	PUT     changes the level, or the override of a named logger pattern, with a
+//	        JSON body {"logger":"billing.*","level":"debug","ttl":10} or the equivalent form values
+//	DELETE  removes the override of ?logger=pattern
+//
+// When ttl is set the level in effect before the change is restored after ttl minutes.
+func (l *Logger) LevelHandler() http.Handler {
+	return newLevelServer(func() *levels { return l.levels })
+}
+
+func newLevelServer(levels func() *levels) *levelServer {
+	return &levelServer{levels: levels, unit: time.Minute, pending: make(map[string]*pendingLevel)}
+}
+
+func (s *levelServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("logger")
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		req, err := decodeLevelRequest(r)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(req.Level)); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		if req.TTL < 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "ttl cannot be negative"})
+			return
+		}
+		s.set(req.Logger, level, time.Duration(req.TTL)*s.unit)
+		name = req.Logger
+	case http.MethodDelete:
+		if name == "" {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "logger is required"})
+			return
+		}
+		s.delete(name)
+		name = ""
+	default:
+		w.Header().Set("Allow", "GET, PUT, DELETE")
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.state(name))
+}
+
+func (s *levelServer) state(name string) levelResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lv := s.levels()
+	var res levelResponse
+	if name == "" {
+		res.Level = lv.Level().String()
+		res.Levels = lv.namedLevels()
+	} else {
+		level, ok := lv.namedLevel(name)
+		if !ok {
+			level = lv.Level()
+		}
+		res.Logger, res.Level = name, level.String()
+	}
+	if p, ok := s.pending[name]; ok {
+		expires := p.expires
+		res.Expires = &expires
+	}
+	return res
+}
+
+// set changes the level of pattern, restoring the level in effect before the
+// first pending change once ttl elapses.
+func (s *levelServer) set(pattern string, level slog.Level, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lv := s.levels()
+	p := s.pending[pattern]
+	if p != nil {
+		p.timer.Stop()
+		delete(s.pending, pattern)
+	}
+	if ttl > 0 {
+		np := &pendingLevel{expires: time.Now().Add(ttl)}
+		if p != nil {
+			np.restore = p.restore
+		} else {
+			np.restore = lv.snapshot(pattern)
+		}
+		np.timer = time.AfterFunc(ttl, func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if s.pending[pattern] != np {
+				return
+			}
+			np.restore()
+			delete(s.pending, pattern)
+		})
+		s.pending[pattern] = np
+	}
+
+	if pattern == "" {
+		lv.Set(level)
+	} else {
+		lv.setNamed(pattern, level)
+	}
+}
+
+func (s *levelServer) delete(pattern string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if p := s.pending[pattern]; p != nil {
+		p.timer.Stop()
+		delete(s.pending, pattern)
+	}
+	s.levels().deleteNamed(pattern)
+}
+
+// snapshot returns a func restoring the current level of pattern.
+func (lv *levels) snapshot(pattern string) func() {
+	if pattern == "" {
+		level := lv.Level()
+		return func() { lv.Set(level) }
+	}
+	level, ok := lv.namedLevels()[pattern]
+	return func() {
+		if ok {
+			lv.setNamed(pattern, level)
+		} else {
+			lv.deleteNamed(pattern)
+		}
+	}
+}
+
+func decodeLevelRequest(r *http.Request) (levelRequest, error) {
+	var req levelRequest
+	if ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); ct == "application/json" {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return req, err
+		}
+	} else {
+		req.Logger = r.FormValue("logger")
+		req.Level = r.FormValue("level")
+		if ttl := r.FormValue("ttl"); ttl != "" {
+			n, err := strconv.Atoi(ttl)
+			if err != nil {
+				return req, errors.New("invalid ttl " + strconv.Quote(ttl))
+			}
+			req.TTL = n
+		}
+	}
+	if req.Level == "" {
+		return req, errors.New("level is required")
+	}
+	return req, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,114 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLevelVar(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := Open(&Config{Mode: ModeCustom, Writer: &buf})
+	require.NoError(t, err)
+
+	l.Debug("hidden")
+	assert.Empty(t, buf.String())
+
+	l.LevelVar().Set(slog.LevelDebug)
+	l.Debug("shown")
+	assert.Contains(t, buf.String(), `"msg":"shown"`)
+}
+
+func TestLevelVar_PerLogger(t *testing.T) {
+	var audit, other bytes.Buffer
+	a := New(&Config{Mode: ModeCustom, Writer: &audit, Level: slog.LevelDebug})
+	b := New(&Config{Mode: ModeCustom, Writer: &other, Level: slog.LevelWarn, Levels: map[string]slog.Level{"*": slog.LevelError}})
+
+	a.Debug("debug")
+	a.Info("info")
+	b.Info("hidden")
+	assert.Contains(t, audit.String(), `"msg":"debug"`)
+	assert.Contains(t, audit.String(), `"msg":"info"`)
+	assert.Empty(t, other.String())
+
+	old := Default()
+	defer SetDefault(old)
+	SetDefault(a)
+	assert.Equal(t, slog.LevelDebug, LevelVar().Level())
+	assert.Empty(t, NamedLevels())
+	Named("billing").Debug("named")
+	assert.Contains(t, audit.String(), `"msg":"named"`)
+}
+
+func TestLevelHandler(t *testing.T) {
+	l, err := Open(&Config{Mode: ModeCustom, Writer: &bytes.Buffer{}})
+	require.NoError(t, err)
+	h := l.LevelHandler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/level", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"level":"INFO"}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPut, "/level", strings.NewReader(`{"level":"warn"}`))
+	req.Header.Set("Content-Type", "application/json")
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"level":"WARN"}`, rec.Body.String())
+	assert.Equal(t, slog.LevelWarn, l.LevelVar().Level())
+	assert.NotEqual(t, slog.LevelWarn, LevelVar().Level(), "the level of Default() is unchanged")
+
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPut, "/level", strings.NewReader(`{"level":"loud"}`))
+	req.Header.Set("Content-Type", "application/json")
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, slog.LevelWarn, l.LevelVar().Level())
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/level", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestLevelHandler_TTL(t *testing.T) {
+	lv := &levels{}
+	h := newLevelServer(func() *levels { return lv })
+	h.unit = 20 * time.Millisecond
+
+	put := func(form url.Values) levelResponse {
+		req := httptest.NewRequest(http.MethodPut, "/level", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var res levelResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+		return res
+	}
+
+	res := put(url.Values{"level": {"debug"}, "ttl": {"1"}})
+	assert.Equal(t, "DEBUG", res.Level)
+	assert.NotNil(t, res.Expires)
+
+	// a second change keeps the original level as the one to restore
+	put(url.Values{"level": {"error"}, "ttl": {"1"}})
+	assert.Equal(t, slog.LevelError, lv.Level())
+
+	assert.Eventually(t, func() bool { return lv.Level() == slog.LevelInfo }, time.Second, 5*time.Millisecond)
+
+	// without ttl the change is permanent
+	res = put(url.Values{"level": {"warn"}})
+	assert.Nil(t, res.Expires)
+	time.Sleep(3 * h.unit)
+	assert.Equal(t, slog.LevelWarn, lv.Level())
+}
@@ -1,10 +1,13 @@
 package logger
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"os"
+	"sync"
 	"sync/atomic"
 
 	"github.com/goapt/logger/rolling"
@@ -13,26 +16,212 @@ import (
 type Mode string
 
 const (
-	ModeFile   Mode = "file"
-	ModeStd    Mode = "std"
-	ModeCustom Mode = "custom"
+	ModeFile    Mode = "file"
+	ModeStd     Mode = "std"
+	ModeStderr  Mode = "stderr"
+	ModeCustom  Mode = "custom"
+	ModeNetwork Mode = "network"
+	ModeSyslog  Mode = "syslog"
+	ModeJournal Mode = "journal" // systemd-journald, Linux only
+	ModeOTLP    Mode = "otlp"    // OpenTelemetry collector over OTLP/HTTP
+)
+
+// Fallback decides what NewE does when the writer of the configured mode cannot be created.
+type Fallback string
+
+const (
+	FallbackFail   Fallback = "fail"   // return the error
+	FallbackStderr Fallback = "stderr" // log to os.Stderr instead and report the error there
 )
 
 type Config struct {
-	Mode     Mode       `json:"mode" yaml:"mode"`           // default  std
-	Level    slog.Level `json:"level" yaml:"level"`         // default info
-	FileName string     `json:"filename" yaml:"filename"`   // only used for file mode
-	MaxFiles int        `json:"max_files" yaml:"max_files"` // default keep the last 3 files
-	MaxSize  int64      `json:"max_size" yaml:"max_size"`   // default 200MB
-	Detail   bool       `json:"detail" yaml:"detail"`       // add file path and line number
-	Writer   io.Writer  `json:"-" yaml:"-"`                 // only used for custom mode
+	Mode     Mode           `json:"mode" yaml:"mode"`           // default  std
+	Format   Format         `json:"format" yaml:"format"`       // json, text, logfmt, console or gelf, default json (console when std mode is a terminal)
+	Level    slog.Level     `json:"level" yaml:"level"`         // default info, see Logger.LevelVar
+	FileName string         `json:"filename" yaml:"filename"`   // only used for file mode
+	MaxFiles int            `json:"max_files" yaml:"max_files"` // default keep the last 3 files
+	MaxSize  int64          `json:"max_size" yaml:"max_size"`   // default 200MB
+	Detail   bool           `json:"detail" yaml:"detail"`       // add file path and line number
+	Writer   io.Writer      `json:"-" yaml:"-"`                 // only used for custom mode
+	Network  *NetworkConfig `json:"network" yaml:"network"`     // only used for network mode
+	Syslog   *SyslogConfig  `json:"syslog" yaml:"syslog"`       // only used for syslog mode, default /dev/log
+	Journal  *JournalConfig `json:"journal" yaml:"journal"`     // only used for journal mode
+	GELF     *GELFConfig    `json:"gelf" yaml:"gelf"`           // only used for gelf format
+	OTLP     *OTLPConfig    `json:"otlp" yaml:"otlp"`           // only used for otlp mode
+
+	TimeFormat string    `json:"time_format" yaml:"time_format"` // Go layout, rfc3339, rfc3339nano, unix, unixmilli, unixmicro or unixnano, default "2006-01-02 15:04:05.000"
+	TimeZone   string    `json:"time_zone" yaml:"time_zone"`     // IANA name such as UTC or Asia/Shanghai, default local time
+	Keys       FieldKeys `json:"keys" yaml:"keys"`               // rename the built-in time, level, msg and source keys
+
+	Levels   map[string]slog.Level `json:"levels" yaml:"levels"`     // level overrides of named loggers, e.g. {"billing.*": "debug"}
+	Fallback Fallback              `json:"fallback" yaml:"fallback"` // fail or stderr, default fail
+	Async    *AsyncConfig          `json:"async" yaml:"async"`       // write through a bounded queue, default synchronous
+	Sampling *SamplingConfig       `json:"sampling" yaml:"sampling"` // drop repeated messages, default log everything
+	Redact   *RedactConfig         `json:"redact" yaml:"redact"`     // mask secrets and PII in attrs, default off
+	Errors   *ErrorsConfig         `json:"errors" yaml:"errors"`     // log errors with their type, causes and stack, default their message
+	Service  *ServiceConfig        `json:"service" yaml:"service"`   // add service name, version, environment, host and pid to every record
+	Fields   map[string]any        `json:"fields" yaml:"fields"`     // static attrs added to every record
+	Ring     *RingConfig           `json:"ring" yaml:"ring"`         // keep the last records in memory, see Logger.Ring
+	// CaptureStdlib routes the log package, at StdlibLevel, and slog.Default()
+	// to this logger until it is closed, see CaptureStdlib.
+	CaptureStdlib bool       `json:"capture_stdlib" yaml:"capture_stdlib"`
+	StdlibLevel   slog.Level `json:"stdlib_level" yaml:"stdlib_level"` // default info
+	// WithContext adds the request ID, trace/span IDs and sloghttp context
+	// attributes of the context to every record logged with a ctx, see ContextHandler.
+	WithContext bool `json:"with_context" yaml:"with_context"`
+
+	// Sinks, when set, replace the output settings above (Mode, Format, FileName,
+	// MaxFiles, MaxSize, Writer and Async): every record enabled by Level is
+	// written to each sink whose own level it reaches.
+	Sinks []SinkConfig `json:"sinks" yaml:"sinks"`
 }
 
+// New creates a logger from conf, it panics if the writer cannot be created,
+// see NewE for a variant returning the error. conf is not validated, an
+// unknown mode writes to os.Stdout.
 func New(conf *Config) *slog.Logger {
+	l, err := open(conf, false)
+	if err != nil {
+		panic(err)
+	}
+	return l.Logger
+}
+
+// NewE creates a logger from conf. If the writer of the configured mode cannot
+// be created, such as an unwritable log file, it returns the error or falls
+// back to os.Stderr according to conf.Fallback. An invalid conf is reported
+// with the errors of Config.Validate.
+// The returned io.Closer is the *Logger owning the resources of the logger.
+func NewE(conf *Config) (*slog.Logger, io.Closer, error) {
+	l, err := Open(conf)
+	if err != nil {
+		return nil, nil, err
+	}
+	return l.Logger, l, nil
+}
+
+// Logger is a *slog.Logger together with the resources backing it, such as
+// log files, buffers and background goroutines.
+type Logger struct {
+	*slog.Logger
+
+	closers []io.Closer  // in the order they were created
+	levels  *levels      // level and named level overrides
+	ring    *RingHandler // nil unless Config.Ring is set
+	once    sync.Once
+	err     error
+}
+
+// Open creates a Logger from conf, writer errors are handled as in NewE.
+// An invalid conf is reported with the errors of Config.Validate.
+// Call Close or Shutdown when the logger is no longer used.
+func Open(conf *Config) (*Logger, error) {
+	return open(conf, true)
+}
+
+func open(conf *Config, validate bool) (*Logger, error) {
 	if conf.Mode == "" {
 		conf.Mode = ModeStd
 	}
-	return slog.New(newHandler(conf))
+	if validate {
+		if err := conf.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	l := &Logger{}
+	h, err := newHandler(conf, l)
+	if err == nil {
+		l.Logger = slog.New(h)
+		l.captureStdlib(conf)
+		return l, nil
+	}
+	_ = l.Close()
+	if conf.Fallback != FallbackStderr {
+		return nil, err
+	}
+
+	fallback := *conf
+	fallback.Mode, fallback.Writer, fallback.Async, fallback.Sinks = ModeCustom, os.Stderr, nil, nil
+	l = &Logger{}
+	h, _ = newHandler(&fallback, l)
+	l.Logger = slog.New(h)
+	l.captureStdlib(conf)
+	l.Error("create log writer failed, falling back to stderr", slog.String("mode", string(conf.Mode)), slog.Any("error", err))
+	return l, nil
+}
+
+func (l *Logger) captureStdlib(conf *Config) {
+	if conf.CaptureStdlib {
+		l.track(closeFunc(CaptureStdlib(l.Logger, conf.StdlibLevel)))
+	}
+}
+
+// Ring returns the in-memory buffer of the last records, nil unless Config.Ring is set.
+func (l *Logger) Ring() *RingHandler { return l.ring }
+
+// Sync flushes buffered records to their writers and commits log files to stable storage.
+func (l *Logger) Sync() error {
+	var errs []error
+	for i := len(l.closers) - 1; i >= 0; i-- {
+		if s, ok := l.closers[i].(interface{ Sync() error }); ok {
+			errs = append(errs, s.Sync())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Dropped returns the number of records dropped by the async queues of l, see AsyncConfig.
+func (l *Logger) Dropped() uint64 {
+	var n uint64
+	for _, c := range l.closers {
+		if d, ok := c.(interface{ Dropped() uint64 }); ok {
+			n += d.Dropped()
+		}
+	}
+	return n
+}
+
+// Close flushes pending records, closes the log files and stops the
+// background goroutines. Records logged afterward are dropped.
+// Close is safe to call more than once.
+func (l *Logger) Close() error {
+	l.once.Do(func() {
+		var errs []error
+		// the last created wraps the earlier ones, so they are closed first
+		for i := len(l.closers) - 1; i >= 0; i-- {
+			errs = append(errs, l.closers[i].Close())
+		}
+		l.err = errors.Join(errs...)
+	})
+	return l.err
+}
+
+// Shutdown is Close bounded by ctx, for use in graceful shutdown hooks.
+// It returns ctx.Err() if ctx is done before Close returns, cancelling the
+// exports in flight of the otlp sinks.
+func (l *Logger) Shutdown(ctx context.Context) error {
+	stop := context.AfterFunc(ctx, func() {
+		for _, c := range l.closers {
+			if c, ok := c.(interface{ cancel() }); ok {
+				c.cancel()
+			}
+		}
+	})
+	defer stop()
+	done := make(chan error, 1)
+	go func() { done <- l.Close() }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// track registers c to be synced and closed with l.
+func (l *Logger) track(c io.Closer) {
+	l.closers = append(l.closers, c)
 }
 
 var defaultLogger atomic.Pointer[slog.Logger]
@@ -46,53 +235,105 @@ func Default() *slog.Logger { return defaultLogger.Load() }
 
 func SetDefault(logger *slog.Logger) { defaultLogger.Store(logger) }
 
-func newRoller(conf *Config) *rolling.Roller {
-	if conf.MaxFiles == 0 {
-		conf.MaxFiles = 3
+func newRoller(sink *SinkConfig) (*rolling.Roller, error) {
+	if sink.MaxFiles == 0 {
+		sink.MaxFiles = 3
 	}
 
-	if conf.FileName == "" {
-		conf.FileName = "app"
+	if sink.FileName == "" {
+		sink.FileName = "app"
 	}
 
-	if conf.MaxSize == 0 {
-		conf.MaxSize = 1024 * 1024 * 200
+	if sink.MaxSize == 0 {
+		sink.MaxSize = 1024 * 1024 * 200
 	}
 
-	roller, err := rolling.NewRoller(conf.FileName, conf.MaxSize, rolling.WithMaxBackups(conf.MaxFiles), rolling.WithMaxAge(3))
+	roller, err := rolling.NewRoller(sink.FileName, sink.MaxSize, rolling.WithMaxBackups(sink.MaxFiles), rolling.WithMaxAge(3))
 	if err != nil {
-		panic(fmt.Sprintf("new file writer error %s", err))
+		return nil, fmt.Errorf("new file writer error: %w", err)
 	}
-	return roller
+	return roller, nil
 }
 
-func newHandler(conf *Config) slog.Handler {
-	isStdout := false
-	var w io.Writer
-	switch conf.Mode {
-	case ModeFile:
-		w = newRoller(conf)
-	case ModeCustom:
-		w = conf.Writer
-	default:
-		w = os.Stdout
-		isStdout = true
+func newHandler(conf *Config, l *Logger) (slog.Handler, error) {
+	l.levels = &levels{}
+	l.levels.Set(conf.Level)
+	l.levels.setAllNamed(conf.Levels)
+
+	replace, err := conf.replaceAttr()
+	if err != nil {
+		return nil, err
+	}
+	opts := &slog.HandlerOptions{
+		AddSource:   conf.Detail,
+		Level:       levelAll,
+		ReplaceAttr: replace,
 	}
 
-	if os.Getenv("DEBUG_LOG") == "true" && !isStdout {
-		w = io.MultiWriter(w, os.Stdout)
+	sinks := conf.Sinks
+	if len(sinks) == 0 {
+		sinks = []SinkConfig{conf.sink()}
+		if os.Getenv("DEBUG_LOG") == "true" && conf.Mode != ModeStd {
+			sinks = append(sinks, SinkConfig{Mode: ModeStd, Level: levelAll, Format: conf.Format})
+		}
 	}
 
-	opts := &slog.HandlerOptions{
-		AddSource: conf.Detail,
-		Level:     conf.Level,
-		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
-			if a.Value.Kind() == slog.KindTime {
-				return slog.String(a.Key, a.Value.Time().Format("2006-01-02 15:04:05.000"))
-			}
-			return a
-		},
+	handlers := make([]slog.Handler, 0, len(sinks))
+	for _, sink := range sinks {
+		h, err := newSinkHandler(&sink, opts, l)
+		if err != nil {
+			return nil, err
+		}
+		handlers = append(handlers, h)
+	}
+
+	var h slog.Handler = &fanoutHandler{handlers: handlers}
+	if len(conf.Sinks) == 0 && len(handlers) == 1 {
+		h = handlers[0]
+	}
+	if h, err = conf.redact(h); err != nil {
+		return nil, err
+	}
+	if conf.Sampling != nil {
+		sh := NewSamplingHandler(h, *conf.Sampling)
+		l.track(sh)
+		h = sh
+	}
+	h = conf.decorate(h)
+
+	var tap slog.Handler
+	if conf.Ring != nil {
+		l.ring = NewRingHandler(*conf.Ring, opts)
+		if tap, err = conf.redact(l.ring); err != nil {
+			return nil, err
+		}
+		tap = conf.decorate(tap)
+	}
+	return newLevelHandler(h, tap, l.levels), nil
+}
+
+// redact wraps h with the handler masking the attrs of records, if any.
+func (c *Config) redact(h slog.Handler) (slog.Handler, error) {
+	if c.Redact == nil {
+		return h, nil
+	}
+	rh, err := NewRedactHandler(h, *c.Redact)
+	if err != nil {
+		return nil, err
 	}
+	return rh, nil
+}
 
-	return slog.NewJSONHandler(w, opts)
+// decorate wraps h with the handlers adding to the attrs of records.
+func (c *Config) decorate(h slog.Handler) slog.Handler {
+	if c.Errors != nil {
+		h = NewErrorHandler(h, *c.Errors)
+	}
+	if c.WithContext {
+		h = NewContextHandler(h)
+	}
+	if attrs := c.staticAttrs(); len(attrs) > 0 {
+		h = h.WithAttrs(attrs)
+	}
+	return h
 }
@@ -2,8 +2,11 @@ package logger
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"log/slog"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -39,3 +42,74 @@ func TestLogger_ModeCustom(t *testing.T) {
 	_, hasSource := m["source"]
 	assert.False(t, hasSource)
 }
+
+func TestNewE_FileError(t *testing.T) {
+	dir := t.TempDir()
+	// a regular file where the log directory should be makes the roller fail
+	blocker := filepath.Join(dir, "blocker")
+	assert.NoError(t, os.WriteFile(blocker, nil, 0644))
+	conf := &Config{Mode: ModeFile, FileName: filepath.Join(blocker, "app.log")}
+
+	l, closer, err := NewE(conf)
+	assert.ErrorContains(t, err, "new file writer error")
+	assert.Nil(t, l)
+	assert.Nil(t, closer)
+
+	assert.Panics(t, func() { New(conf) })
+
+	conf.Fallback = FallbackStderr
+	l, closer, err = NewE(conf)
+	assert.NoError(t, err)
+	assert.NotNil(t, l)
+	assert.NoError(t, closer.Close())
+}
+
+func TestNewE_InvalidConfig(t *testing.T) {
+	conf := &Config{Mode: ModeCustom, Writer: &bytes.Buffer{}, Format: "xml", Fallback: FallbackStderr}
+	l, closer, err := NewE(conf)
+	assert.ErrorContains(t, err, `unknown format "xml"`)
+	assert.Nil(t, l)
+	assert.Nil(t, closer)
+}
+
+func TestNew_UnknownMode(t *testing.T) {
+	conf := &Config{Mode: "stdout", Fallback: FallbackStderr}
+	assert.NotPanics(t, func() { New(conf) }, "New keeps writing unknown modes to stdout")
+
+	_, _, err := NewE(conf)
+	assert.ErrorContains(t, err, `unknown mode "stdout"`)
+}
+
+func TestNewE_File(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "app.log")
+	l, closer, err := NewE(&Config{Mode: ModeFile, FileName: name})
+	assert.NoError(t, err)
+
+	l.Info("hello")
+	assert.NoError(t, closer.Close())
+
+	data, err := os.ReadFile(name)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"msg":"hello"`)
+}
+
+func TestOpen_Lifecycle(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "app.log")
+	l, err := Open(&Config{Mode: ModeFile, FileName: name})
+	assert.NoError(t, err)
+
+	l.Info("hello")
+	assert.NoError(t, l.Sync())
+	data, err := os.ReadFile(name)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"msg":"hello"`)
+
+	assert.NoError(t, l.Shutdown(context.Background()))
+	assert.NoError(t, l.Close())
+
+	// records logged after Close are dropped
+	l.Info("dropped")
+	data, err = os.ReadFile(name)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "dropped")
+}
@@ -0,0 +1,62 @@
+package logtest
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// VolatileKeys are the keys whose values Normalize replaces, at any depth.
+var VolatileKeys = []string{"time", "latency", "request_id"}
+
+// UpdateEnv is the environment variable making AssertGolden write the golden
+// files instead of comparing with them, e.g. LOGTEST_UPDATE=1 go test ./...
+var UpdateEnv = "LOGTEST_UPDATE"
+
+// Normalize replaces the values of VolatileKeys in JSON, text and logfmt logs
+// with the key in angle brackets, such as "time":"<time>" or time=<time>.
+func Normalize(data []byte) []byte {
+	keys := make([]string, len(VolatileKeys))
+	for i, key := range VolatileKeys {
+		keys[i] = regexp.QuoteMeta(key)
+	}
+	alt := strings.Join(keys, "|")
+
+	jsonRe := regexp.MustCompile(`"(` + alt + `)":("(?:[^"\\]|\\.)*"|[^,}\]]+)`)
+	data = jsonRe.ReplaceAll(data, []byte(`"$1":"<$1>"`))
+
+	textRe := regexp.MustCompile(`(^|[ .])(` + alt + `)=("(?:[^"\\]|\\.)*"|[^ \n]*)`)
+	lines := bytes.Split(data, []byte("\n"))
+	for i, line := range lines {
+		lines[i] = textRe.ReplaceAll(line, []byte("$1$2=<$2>"))
+	}
+	return bytes.Join(lines, []byte("\n"))
+}
+
+// AssertGolden compares the normalized got with the golden file at path, or
+// writes it there when the UpdateEnv variable is set.
+func AssertGolden(tb testing.TB, path string, got []byte) {
+	tb.Helper()
+	got = Normalize(got)
+
+	if os.Getenv(UpdateEnv) != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			tb.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			tb.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		tb.Fatalf("read golden file: %v, run with %s=1 to create it", err, UpdateEnv)
+	}
+	if !bytes.Equal(got, want) {
+		tb.Errorf("logs differ from %s, run with %s=1 to update it\ngot:\n%s\nwant:\n%s", path, UpdateEnv, got, want)
+	}
+}
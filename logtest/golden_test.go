@@ -0,0 +1,48 @@
+package logtest
+
+import (
+	"bytes"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	in := `{"time":"2024-01-02 15:04:05.000","level":"INFO","msg":"200: OK","request_id":"4b1c","response":{"time":"2024-01-02T15:04:05Z","latency":1234567,"status":200}}
+time=2024-01-02T15:04:05Z level=INFO msg=ok request_id=4b1c response.latency=1.2ms uptime=3
+time="2024-01-02 15:04:05.000" level=INFO msg="no request"
+`
+	want := `{"time":"<time>","level":"INFO","msg":"200: OK","request_id":"<request_id>","response":{"time":"<time>","latency":"<latency>","status":200}}
+time=<time> level=INFO msg=ok request_id=<request_id> response.latency=<latency> uptime=3
+time=<time> level=INFO msg="no request"
+`
+	assert.Equal(t, want, string(Normalize([]byte(in))))
+}
+
+func TestAssertGolden(t *testing.T) {
+	t.Setenv(UpdateEnv, "")
+
+	var buf bytes.Buffer
+	l := slog.New(slog.NewJSONHandler(&buf, nil))
+	l.Info("200: OK", "request_id", "4b1c", slog.Group("response", "latency", 3*time.Millisecond, "status", 200))
+	l.Warn("slow", "latency", time.Second)
+
+	AssertGolden(t, filepath.Join("testdata", "access.golden"), buf.Bytes())
+
+	ft := &fakeTB{TB: t}
+	AssertGolden(ft, filepath.Join("testdata", "access.golden"), []byte("other\n"))
+	assert.True(t, ft.failed)
+
+	ft = &fakeTB{TB: t}
+	AssertGolden(ft, filepath.Join("testdata", "missing.golden"), buf.Bytes())
+	assert.True(t, ft.failed)
+
+	t.Setenv(UpdateEnv, "1")
+	path := filepath.Join(t.TempDir(), "new.golden")
+	AssertGolden(t, path, buf.Bytes())
+	t.Setenv(UpdateEnv, "")
+	AssertGolden(t, path, buf.Bytes())
+}
@@ -0,0 +1,204 @@
+// Package logtest helps testing code that logs with log/slog: a Recorder
+// keeps the records for assertions, NewTBHandler routes them through t.Log and
+// AssertGolden compares rendered logs with golden files.
+package logtest
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Record is a record kept by a Recorder.
+type Record struct {
+	Time    time.Time
+	Level   slog.Level
+	Message string
+	Attrs   []slog.Attr // resolved attrs of the record and its logger, group keys joined with dots
+}
+
+// Attr returns the value of the attr with the dotted key.
+func (r Record) Attr(key string) (slog.Value, bool) {
+	for _, a := range r.Attrs {
+		if a.Key == key {
+			return a.Value, true
+		}
+	}
+	return slog.Value{}, false
+}
+
+// AssertAttr checks that r has the attr key equal to want.
+func (r Record) AssertAttr(tb testing.TB, key string, want any) {
+	tb.Helper()
+	got, ok := r.Attr(key)
+	if !ok {
+		tb.Errorf("record %q has no attr %q, attrs: %v", r.Message, key, r.Attrs)
+		return
+	}
+	if !valueEqual(got, slog.AnyValue(want).Resolve()) {
+		tb.Errorf("record %q attr %q = %v, want %v", r.Message, key, got, want)
+	}
+}
+
+// Recorder is a slog.Handler keeping every record it handles, its derived
+// handlers share the records.
+type Recorder struct {
+	state  *recorderState
+	attrs  []slog.Attr
+	prefix string
+}
+
+type recorderState struct {
+	mu      sync.Mutex
+	records []Record
+}
+
+// NewRecorder creates an empty Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{state: &recorderState{}}
+}
+
+func (h *Recorder) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *Recorder) Handle(_ context.Context, r slog.Record) error {
+	rec := Record{Time: r.Time, Level: r.Level, Message: r.Message, Attrs: slices.Clip(h.attrs)}
+	r.Attrs(func(a slog.Attr) bool {
+		rec.Attrs = appendFlat(rec.Attrs, h.prefix, a)
+		return true
+	})
+	h.state.mu.Lock()
+	defer h.state.mu.Unlock()
+	h.state.records = append(h.state.records, rec)
+	return nil
+}
+
+func (h *Recorder) WithAttrs(attrs []slog.Attr) slog.Handler {
+	flat := slices.Clip(h.attrs)
+	for _, a := range attrs {
+		flat = appendFlat(flat, h.prefix, a)
+	}
+	return &Recorder{state: h.state, attrs: flat, prefix: h.prefix}
+}
+
+func (h *Recorder) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &Recorder{state: h.state, attrs: h.attrs, prefix: h.prefix + name + "."}
+}
+
+// Records returns the records handled so far, oldest first.
+func (h *Recorder) Records() []Record {
+	h.state.mu.Lock()
+	defer h.state.mu.Unlock()
+	return slices.Clone(h.state.records)
+}
+
+// Reset forgets the records handled so far.
+func (h *Recorder) Reset() {
+	h.state.mu.Lock()
+	defer h.state.mu.Unlock()
+	h.state.records = nil
+}
+
+// Find returns the records for which match returns true.
+func (h *Recorder) Find(match func(Record) bool) []Record {
+	var records []Record
+	for _, r := range h.Records() {
+		if match(r) {
+			records = append(records, r)
+		}
+	}
+	return records
+}
+
+// Logged returns the records with the level and message.
+func (h *Recorder) Logged(level slog.Level, msg string) []Record {
+	return h.Find(func(r Record) bool { return r.Level == level && r.Message == msg })
+}
+
+// Count returns the number of records with the message, at any level.
+func (h *Recorder) Count(msg string) int {
+	return len(h.Find(func(r Record) bool { return r.Message == msg }))
+}
+
+// AssertLogged checks that a record with the level and message was handled
+// and returns the first one.
+func (h *Recorder) AssertLogged(tb testing.TB, level slog.Level, msg string) Record {
+	tb.Helper()
+	records := h.Logged(level, msg)
+	if len(records) == 0 {
+		tb.Errorf("no %s record %q, got:\n%s", level, msg, h.summary())
+		return Record{}
+	}
+	return records[0]
+}
+
+// AssertNotLogged checks that no record with the message was handled at level or above.
+func (h *Recorder) AssertNotLogged(tb testing.TB, level slog.Level, msg string) {
+	tb.Helper()
+	if records := h.Find(func(r Record) bool { return r.Level >= level && r.Message == msg }); len(records) > 0 {
+		tb.Errorf("unexpected %s record %q", records[0].Level, msg)
+	}
+}
+
+// AssertCount checks that n records with the message were handled.
+func (h *Recorder) AssertCount(tb testing.TB, n int, msg string) {
+	tb.Helper()
+	if got := h.Count(msg); got != n {
+		tb.Errorf("got %d records %q, want %d", got, msg, n)
+	}
+}
+
+// AssertOrder checks that records with the messages were handled in this
+// order, other records may come in between.
+func (h *Recorder) AssertOrder(tb testing.TB, msgs ...string) {
+	tb.Helper()
+	i := 0
+	for _, r := range h.Records() {
+		if i < len(msgs) && r.Message == msgs[i] {
+			i++
+		}
+	}
+	if i < len(msgs) {
+		tb.Errorf("record %q not found in order %q, got:\n%s", msgs[i], msgs, h.summary())
+	}
+}
+
+func (h *Recorder) summary() string {
+	var b strings.Builder
+	for _, r := range h.Records() {
+		fmt.Fprintf(&b, "\t%s %q %v\n", r.Level, r.Message, r.Attrs)
+	}
+	return b.String()
+}
+
+func valueEqual(a, b slog.Value) bool {
+	if a.Kind() == slog.KindAny || b.Kind() == slog.KindAny {
+		return reflect.DeepEqual(a.Any(), b.Any())
+	}
+	return a.Equal(b)
+}
+
+func appendFlat(attrs []slog.Attr, prefix string, a slog.Attr) []slog.Attr {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return attrs
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			attrs = appendFlat(attrs, prefix, ga)
+		}
+		return attrs
+	}
+	return append(attrs, slog.Attr{Key: prefix + a.Key, Value: a.Value})
+}
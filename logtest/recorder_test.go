@@ -0,0 +1,68 @@
+package logtest
+
+import (
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecorder(t *testing.T) {
+	rec := NewRecorder()
+	l := slog.New(rec).With("app", "demo")
+
+	l.Debug("start")
+	l.WithGroup("user").Info("login", "id", 42, "roles", []string{"admin"})
+	l.Warn("retry", slog.Group("db", "table", "orders"))
+	l.Warn("retry")
+	l.Error("failed", "error", errors.New("boom"))
+
+	assert.Len(t, rec.Records(), 5)
+	assert.Equal(t, 2, rec.Count("retry"))
+	assert.Len(t, rec.Logged(slog.LevelWarn, "retry"), 2)
+	assert.Empty(t, rec.Logged(slog.LevelInfo, "retry"))
+
+	login := rec.AssertLogged(t, slog.LevelInfo, "login")
+	login.AssertAttr(t, "app", "demo")
+	login.AssertAttr(t, "user.id", 42)
+	login.AssertAttr(t, "user.roles", []string{"admin"})
+	rec.AssertLogged(t, slog.LevelWarn, "retry").AssertAttr(t, "db.table", "orders")
+	rec.AssertNotLogged(t, slog.LevelInfo, "start")
+	rec.AssertCount(t, 1, "failed")
+	rec.AssertOrder(t, "start", "login", "failed")
+
+	rec.Reset()
+	assert.Empty(t, rec.Records())
+}
+
+func TestRecorder_Failures(t *testing.T) {
+	rec := NewRecorder()
+	l := slog.New(rec)
+	l.Info("first", "n", 1)
+	l.Info("second")
+
+	for name, assertion := range map[string]func(tb testing.TB){
+		"logged":     func(tb testing.TB) { rec.AssertLogged(tb, slog.LevelError, "first") },
+		"not logged": func(tb testing.TB) { rec.AssertNotLogged(tb, slog.LevelDebug, "first") },
+		"count":      func(tb testing.TB) { rec.AssertCount(tb, 2, "first") },
+		"order":      func(tb testing.TB) { rec.AssertOrder(tb, "second", "first") },
+		"attr":       func(tb testing.TB) { rec.Records()[0].AssertAttr(tb, "n", 2) },
+		"no attr":    func(tb testing.TB) { rec.Records()[0].AssertAttr(tb, "m", 1) },
+	} {
+		ft := &fakeTB{TB: t}
+		assertion(ft)
+		assert.True(t, ft.failed, name)
+	}
+}
+
+// fakeTB records failures instead of failing the test.
+type fakeTB struct {
+	testing.TB
+	failed bool
+}
+
+func (f *fakeTB) Helper()               {}
+func (f *fakeTB) Errorf(string, ...any) { f.failed = true }
+func (f *fakeTB) Fatalf(string, ...any) { f.failed = true }
+func (f *fakeTB) Fatal(...any)          { f.failed = true }
@@ -0,0 +1,41 @@
+package logtest
+
+import (
+	"bytes"
+	"log/slog"
+	"sync"
+	"testing"
+)
+
+// NewTBHandler returns a text handler writing each record to tb.Log, so logs
+// show up with the output of the test that produced them. Records handled
+// after the test finished are dropped.
+func NewTBHandler(tb testing.TB, opts *slog.HandlerOptions) slog.Handler {
+	w := &tbWriter{tb: tb}
+	tb.Cleanup(func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		w.done = true
+	})
+	return slog.NewTextHandler(w, opts)
+}
+
+// NewLogger returns a *slog.Logger logging through tb.Log at every level.
+func NewLogger(tb testing.TB) *slog.Logger {
+	return slog.New(NewTBHandler(tb, &slog.HandlerOptions{Level: slog.LevelDebug}))
+}
+
+type tbWriter struct {
+	mu   sync.Mutex
+	tb   testing.TB
+	done bool
+}
+
+func (w *tbWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.done {
+		w.tb.Log(string(bytes.TrimSuffix(p, []byte("\n"))))
+	}
+	return len(p), nil
+}
@@ -0,0 +1,31 @@
+package logtest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type logTB struct {
+	testing.TB
+	lines   []string
+	cleanup func()
+}
+
+func (l *logTB) Log(args ...any)  { l.lines = append(l.lines, args[0].(string)) }
+func (l *logTB) Cleanup(f func()) { l.cleanup = f }
+
+func TestNewLogger(t *testing.T) {
+	tb := &logTB{TB: t}
+	l := NewLogger(tb)
+	l.Debug("hello", "k", "v")
+	assert.Len(t, tb.lines, 1)
+	assert.Regexp(t, `^time=\S+ level=DEBUG msg=hello k=v$`, tb.lines[0])
+
+	tb.cleanup()
+	l.Info("after the test")
+	assert.Len(t, tb.lines, 1)
+
+	// a real testing.T
+	NewLogger(t).Info("routed through t.Log")
+}
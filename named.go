@@ -0,0 +1,247 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// NameKey is the key of the attr added by Named.
+var NameKey = "logger"
+
+// levelAll enables every record, the handlers below levelHandler are built with it.
+const levelAll = slog.Level(math.MinInt)
+
+// levels holds the level of a Logger and the overrides of its named loggers.
+type levels struct {
+	slog.LevelVar
+	named   atomic.Pointer[map[string]slog.Level]
+	namedMu sync.Mutex // serializes writers of named
+}
+
+// detachedLevels stands for the levels of a Default() not built by New, so
+// changing them has no effect.
+var detachedLevels levels
+
+// defaultLevels returns the levels of Default().
+func defaultLevels() *levels {
+	if h, ok := Default().Handler().(*levelHandler); ok && h.levels != nil {
+		return h.levels
+	}
+	return &detachedLevels
+}
+
+// Named returns a child of Default() whose records carry the attr logger=name.
+// Its level can be overridden independently of the level of Default() with
+// SetNamedLevel or Config.Levels.
+func Named(name string) *slog.Logger {
+	l := Default()
+	h, ok := l.Handler().(*levelHandler)
+	if !ok {
+		h = newLevelHandler(l.Handler(), nil, nil)
+	}
+	named := h.WithAttrs([]slog.Attr{slog.String(NameKey, name)}).(*levelHandler)
+	named.name = name
+	return slog.New(named)
+}
+
+// SetNamedLevel overrides the level of the loggers created by Named whose name
+// matches pattern. A pattern is either an exact name or a prefix ending with
+// ".*" such as "billing.*", which matches "billing" and every name below it.
+// When several patterns match, the exact name wins, then the longest prefix.
+// The overrides belong to Default() and are replaced along with it.
+func SetNamedLevel(pattern string, level slog.Level) { defaultLevels().setNamed(pattern, level) }
+
+// DeleteNamedLevel removes the override set for pattern.
+func DeleteNamedLevel(pattern string) { defaultLevels().deleteNamed(pattern) }
+
+// NamedLevels returns the current overrides, the map must not be modified.
+func NamedLevels() map[string]slog.Level { return defaultLevels().namedLevels() }
+
+func (lv *levels) setNamed(pattern string, level slog.Level) {
+	lv.namedMu.Lock()
+	defer lv.namedMu.Unlock()
+	m := maps.Clone(lv.namedLevels())
+	if m == nil {
+		m = make(map[string]slog.Level)
+	}
+	m[pattern] = level
+	lv.named.Store(&m)
+}
+
+func (lv *levels) deleteNamed(pattern string) {
+	lv.namedMu.Lock()
+	defer lv.namedMu.Unlock()
+	m := maps.Clone(lv.namedLevels())
+	delete(m, pattern)
+	lv.named.Store(&m)
+}
+
+func (lv *levels) setAllNamed(levels map[string]slog.Level) {
+	lv.namedMu.Lock()
+	defer lv.namedMu.Unlock()
+	m := maps.Clone(levels)
+	lv.named.Store(&m)
+}
+
+func (lv *levels) namedLevels() map[string]slog.Level {
+	if m := lv.named.Load(); m != nil {
+		return *m
+	}
+	return nil
+}
+
+// namedLevel returns the level override matching name.
+func (lv *levels) namedLevel(name string) (slog.Level, bool) {
+	m := lv.namedLevels()
+	if len(m) == 0 {
+		return 0, false
+	}
+	if level, ok := m[name]; ok {
+		return level, true
+	}
+
+	var (
+		level slog.Level
+		best  = -1
+	)
+	for pattern, l := range m {
+		prefix, ok := strings.CutSuffix(pattern, "*")
+		if !ok || len(prefix) <= best {
+			continue
+		}
+		if prefix == "" || name+"." == prefix || strings.HasPrefix(name, prefix) {
+			level, best = l, len(prefix)
+		}
+	}
+	return level, best >= 0
+}
+
+// levelHandler is the outermost handler of the loggers built by New, it
+// decides whether a record is enabled from the level of the logger or, for
+// named loggers, from the matching override. Records it does not enable are held
+// by the buffer of a BufferContext, if any. The handlers below it can be
+// swapped, see swap, the handlers derived from it with WithAttrs and
+// WithGroup follow.
+type levelHandler struct {
+	root   *atomic.Pointer[levelChain] // shared by the handlers derived from the same New
+	levels *levels                     // nil defers to next
+	name   string
+	derive []func(slog.Handler) slog.Handler // the WithAttrs and WithGroup calls since New, in order
+	chain  atomic.Pointer[levelChain]        // root with derive applied, rebuilt once root is swapped
+}
+
+// levelChain holds the handlers below a levelHandler.
+type levelChain struct {
+	next slog.Handler
+	tap  slog.Handler // nil or given the records it enables whatever the level, such as a RingHandler
+	from *levelChain  // the root chain it derives from
+}
+
+func newLevelHandler(next, tap slog.Handler, levels *levels) *levelHandler {
+	c := &levelChain{next: next, tap: tap}
+	c.from = c
+	h := &levelHandler{root: new(atomic.Pointer[levelChain]), levels: levels}
+	h.root.Store(c)
+	h.chain.Store(c)
+	return h
+}
+
+// current returns the handlers of h derived from the current root chain.
+func (h *levelHandler) current() *levelChain {
+	root := h.root.Load()
+	if c := h.chain.Load(); c.from == root {
+		return c
+	}
+	c := &levelChain{next: root.next, tap: root.tap, from: root}
+	for _, fn := range h.derive {
+		c.next = fn(c.next)
+		if c.tap != nil {
+			c.tap = fn(c.tap)
+		}
+	}
+	h.chain.Store(c)
+	return c
+}
+
+// swap sends the records of h, and of the handlers derived from it, to the
+// handlers of src, with the levels of src.
+func (h *levelHandler) swap(src *levelHandler) {
+	if h.levels != nil && src.levels != nil {
+		h.levels.Set(src.levels.Level())
+		h.levels.setAllNamed(src.levels.namedLevels())
+	}
+	h.root.Store(src.root.Load())
+}
+
+func (h *levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	c := h.current()
+	if h.enabled(ctx, c, level) || (c.tap != nil && c.tap.Enabled(ctx, level)) {
+		return true
+	}
+	b := bufferFrom(ctx)
+	return b != nil && b.holds(level)
+}
+
+// enabled reports whether a record is enabled for next.
+func (h *levelHandler) enabled(ctx context.Context, c *levelChain, level slog.Level) bool {
+	if h.levels == nil {
+		return c.next.Enabled(ctx, level)
+	}
+	if h.name != "" {
+		if l, ok := h.levels.namedLevel(h.name); ok {
+			return level >= l
+		}
+	}
+	return level >= h.levels.Level()
+}
+
+func (h *levelHandler) Handle(ctx context.Context, r slog.Record) error {
+	c := h.current()
+	var err error
+	if c.tap != nil && c.tap.Enabled(ctx, r.Level) {
+		err = c.tap.Handle(ctx, r)
+	}
+	enabled := h.enabled(ctx, c, r.Level)
+	if b := bufferFrom(ctx); b != nil {
+		if !enabled {
+			if b.holds(r.Level) {
+				b.add(c.next, ctx, r)
+			}
+			return err
+		}
+		if r.Level >= slog.LevelError {
+			writeBuffered(b.take(false))
+		}
+	}
+	if enabled {
+		err = errors.Join(err, c.next.Handle(ctx, r))
+	}
+	return err
+}
+
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(func(h slog.Handler) slog.Handler { return h.WithAttrs(attrs) })
+}
+
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return h.with(func(h slog.Handler) slog.Handler { return h.WithGroup(name) })
+}
+
+// with returns a copy of h whose handlers are derived with fn.
+func (h *levelHandler) with(fn func(slog.Handler) slog.Handler) *levelHandler {
+	c := h.current()
+	d := &levelChain{next: fn(c.next), from: c.from}
+	if c.tap != nil {
+		d.tap = fn(c.tap)
+	}
+	h2 := &levelHandler{root: h.root, levels: h.levels, name: h.name, derive: append(slices.Clip(h.derive), fn)}
+	h2.chain.Store(d)
+	return h2
+}
@@ -0,0 +1,105 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamed(t *testing.T) {
+	var buf bytes.Buffer
+	old := Default()
+	defer SetDefault(old)
+
+	SetDefault(New(&Config{
+		Mode:   ModeCustom,
+		Writer: &buf,
+		Levels: map[string]slog.Level{"billing.*": slog.LevelDebug},
+	}))
+
+	Named("billing.invoice").Debug("invoice")
+	Named("billing").Debug("billing")
+	Named("billingx").Debug("billingx")
+	Named("cache").Debug("cache")
+	Default().Debug("root")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var m map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &m))
+	assert.Equal(t, "invoice", m["msg"])
+	assert.Equal(t, "billing.invoice", m[NameKey])
+	assert.Contains(t, lines[1], `"msg":"billing"`)
+
+	buf.Reset()
+	SetNamedLevel("cache", slog.LevelDebug)
+	SetNamedLevel("billing.invoice", slog.LevelError)
+	Named("cache").Debug("cache")
+	Named("billing.invoice").Warn("invoice")
+	Named("billing.refund").Debug("refund")
+	assert.Contains(t, buf.String(), `"msg":"cache"`)
+	assert.NotContains(t, buf.String(), `"msg":"invoice"`)
+	assert.Contains(t, buf.String(), `"msg":"refund"`)
+
+	buf.Reset()
+	DeleteNamedLevel("cache")
+	Named("cache").Debug("cache")
+	assert.Empty(t, buf.String())
+}
+
+func TestNamedLevel(t *testing.T) {
+	var lv levels
+	lv.setAllNamed(map[string]slog.Level{
+		"*":            slog.LevelWarn,
+		"billing.*":    slog.LevelDebug,
+		"billing.tx.*": slog.LevelError,
+		"billing.tx":   slog.LevelInfo,
+	})
+
+	for name, want := range map[string]slog.Level{
+		"other":         slog.LevelWarn,
+		"billing":       slog.LevelDebug,
+		"billing.a":     slog.LevelDebug,
+		"billing.tx":    slog.LevelInfo,
+		"billing.tx.db": slog.LevelError,
+	} {
+		got, ok := lv.namedLevel(name)
+		assert.True(t, ok, name)
+		assert.Equal(t, want, got, name)
+	}
+}
+
+func TestLevelHandler_Named(t *testing.T) {
+	l, err := Open(&Config{Mode: ModeCustom, Writer: &bytes.Buffer{}})
+	require.NoError(t, err)
+	h := l.LevelHandler()
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPut, "/level", strings.NewReader(`{"logger":"billing.*","level":"debug"}`))
+	req.Header.Set("Content-Type", "application/json")
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"logger":"billing.*","level":"DEBUG"}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/level?logger=billing.invoice", nil))
+	assert.JSONEq(t, `{"logger":"billing.invoice","level":"DEBUG"}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/level", nil))
+	assert.JSONEq(t, `{"level":"INFO","levels":{"billing.*":"DEBUG"}}`, rec.Body.String())
+	assert.Empty(t, NamedLevels(), "the overrides of Default() are unchanged")
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/level?logger=billing.*", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, l.levels.namedLevels())
+}
@@ -0,0 +1,453 @@
+package logger
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type NetworkConfig struct {
+	Network      string        `json:"network" yaml:"network"`             // tcp, udp, unix or unixgram, default tcp
+	Address      string        `json:"address" yaml:"address"`             // host:port, or the socket path of unix networks
+	TLS          *TLSConfig    `json:"tls" yaml:"tls"`                     // only used for tcp, default plain text
+	DialTimeout  time.Duration `json:"dial_timeout" yaml:"dial_timeout"`   // default 5s
+	WriteTimeout time.Duration `json:"write_timeout" yaml:"write_timeout"` // default 5s
+	MinBackoff   time.Duration `json:"min_backoff" yaml:"min_backoff"`     // first delay between reconnects, doubled up to MaxBackoff, default 100ms
+	MaxBackoff   time.Duration `json:"max_backoff" yaml:"max_backoff"`     // default 30s
+	SpoolSize    int64         `json:"spool_size" yaml:"spool_size"`       // default 8MB of records held while disconnected, the oldest are dropped beyond it
+	SpoolDir     string        `json:"spool_dir" yaml:"spool_dir"`         // hold them in a file of this directory, kept across restarts, default in memory
+
+	// OnState is called from the writer goroutine on every change of the connection state.
+	OnState func(state NetState, err error) `json:"-" yaml:"-"`
+}
+
+type TLSConfig struct {
+	CAFile             string `json:"ca_file" yaml:"ca_file"`                           // PEM roots verifying the collector, default the system roots
+	CertFile           string `json:"cert_file" yaml:"cert_file"`                       // PEM client certificate, with KeyFile
+	KeyFile            string `json:"key_file" yaml:"key_file"`                         // PEM client key
+	ServerName         string `json:"server_name" yaml:"server_name"`                   // default the host of Address
+	InsecureSkipVerify bool   `json:"insecure_skip_verify" yaml:"insecure_skip_verify"` // do not verify the collector, for tests only
+}
+
+// NetState is the state of the connection of a NetWriter.
+type NetState int32
+
+const (
+	NetConnecting NetState = iota
+	NetConnected
+	NetDisconnected // waiting to reconnect, records are spooled
+	NetClosed
+)
+
+func (s NetState) String() string {
+	switch s {
+	case NetConnecting:
+		return "connecting"
+	case NetConnected:
+		return "connected"
+	case NetDisconnected:
+		return "disconnected"
+	case NetClosed:
+		return "closed"
+	}
+	return fmt.Sprintf("NetState(%d)", int32(s))
+}
+
+// NetWriter streams records to a collector over TCP, UDP or a Unix socket.
+// Each line written is a record: stream networks get newline-delimited
+// records, datagram networks a record per datagram. Writes never block on the
+// network, records are spooled and sent by a background goroutine which
+// reconnects with exponential backoff. The spool is bounded by SpoolSize, the
+// oldest records are dropped beyond it. Records are sent at least once, a
+// connection failure may resend the records being written.
+type NetWriter struct {
+	conf     NetworkConfig
+	tls      *tls.Config
+	datagram bool
+	framing  netFraming
+
+	mu      sync.Mutex
+	changed *sync.Cond // signaled when records are sent or the state changes
+	spool   spool
+	evicted uint64 // records dropped from the head of the spool
+	state   NetState
+	closing bool
+
+	dropped atomic.Uint64
+	wake    chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+	err     error
+}
+
+// NewNetWriter creates a NetWriter and starts connecting to the collector.
+// Close it to send the spooled records and stop the goroutine.
+func NewNetWriter(conf NetworkConfig) (*NetWriter, error) {
+	return newNetWriter(conf, netFraming{})
+}
+
+// netFraming encodes the records sent by a NetWriter.
+type netFraming struct {
+	// frame appends the framed record to dst, by default with a newline after
+	// the records of stream networks and as is for datagram networks.
+	frame func(dst, rec []byte) []byte
+	// datagrams, when set, returns the datagrams carrying rec on datagram
+	// networks instead of frame, none to drop it.
+	datagrams func(rec []byte) [][]byte
+}
+
+// newNetWriter creates a NetWriter sending the records encoded by framing.
+func newNetWriter(conf NetworkConfig, framing netFraming) (*NetWriter, error) {
+	if conf.Network == "" {
+		conf.Network = "tcp"
+	}
+	if conf.DialTimeout <= 0 {
+		conf.DialTimeout = 5 * time.Second
+	}
+	if conf.WriteTimeout <= 0 {
+		conf.WriteTimeout = 5 * time.Second
+	}
+	if conf.MinBackoff <= 0 {
+		conf.MinBackoff = 100 * time.Millisecond
+	}
+	if conf.MaxBackoff < conf.MinBackoff {
+		conf.MaxBackoff = max(30*time.Second, conf.MinBackoff)
+	}
+	if conf.SpoolSize <= 0 {
+		conf.SpoolSize = 8 * 1024 * 1024
+	}
+
+	w := &NetWriter{
+		conf:     conf,
+		datagram: isDatagram(conf.Network),
+		framing:  framing,
+		wake:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	w.changed = sync.NewCond(&w.mu)
+	if w.framing.frame == nil {
+		w.framing.frame = func(dst, rec []byte) []byte { return append(dst, rec...) }
+		if !w.datagram {
+			w.framing.frame = func(dst, rec []byte) []byte { return append(append(dst, rec...), '\n') }
+		}
+	}
+
+	if conf.TLS != nil {
+		if conf.Network != "tcp" && conf.Network != "tcp4" && conf.Network != "tcp6" {
+			return nil, fmt.Errorf("tls requires a tcp network, got %q", conf.Network)
+		}
+		var err error
+		if w.tls, err = conf.TLS.config(); err != nil {
+			return nil, err
+		}
+	}
+
+	if conf.SpoolDir != "" {
+		s, err := openDiskSpool(conf.SpoolDir, conf.Network, conf.Address, conf.SpoolSize)
+		if err != nil {
+			return nil, err
+		}
+		w.spool = s
+	} else {
+		w.spool = &memSpool{max: conf.SpoolSize}
+	}
+
+	go w.run()
+	return w, nil
+}
+
+func isDatagram(network string) bool {
+	switch network {
+	case "udp", "udp4", "udp6", "unixgram":
+		return true
+	}
+	return false
+}
+
+func (c *TLSConfig) config() (*tls.Config, error) {
+	conf := &tls.Config{ServerName: c.ServerName, InsecureSkipVerify: c.InsecureSkipVerify}
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read tls ca_file: %w", err)
+		}
+		conf.RootCAs = x509.NewCertPool()
+		if !conf.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate in tls ca_file %s", c.CAFile)
+		}
+	}
+	if c.CertFile != "" || c.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load tls client certificate: %w", err)
+		}
+		conf.Certificates = []tls.Certificate{cert}
+	}
+	return conf, nil
+}
+
+// Write spools each line of p as a record, it never blocks on the network.
+func (w *NetWriter) Write(p []byte) (int, error) {
+	for line := range bytes.Lines(p) {
+		if line = bytes.TrimSuffix(line, []byte("\n")); len(line) > 0 {
+			w.push(line)
+		}
+	}
+	return len(p), nil
+}
+
+// push spools rec as a single record.
+func (w *NetWriter) push(rec []byte) {
+	w.mu.Lock()
+	if w.state == NetClosed {
+		w.mu.Unlock()
+		w.dropped.Add(1)
+		return
+	}
+	n, ok := w.spool.push(rec)
+	w.evicted += uint64(n)
+	w.mu.Unlock()
+	if !ok {
+		n++
+	}
+	w.dropped.Add(uint64(n))
+
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// State returns the state of the connection.
+func (w *NetWriter) State() NetState {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.state
+}
+
+// Pending returns the number of spooled records.
+func (w *NetWriter) Pending() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.spool.len()
+}
+
+// Dropped returns the number of records dropped because the spool was full or
+// the writer closed.
+func (w *NetWriter) Dropped() uint64 { return w.dropped.Load() }
+
+// Sync waits until the spooled records are sent, it fails if the collector
+// is disconnected.
+func (w *NetWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for w.spool.len() > 0 && (w.state == NetConnecting || w.state == NetConnected) {
+		w.changed.Wait()
+	}
+	if n := w.spool.len(); n > 0 {
+		return fmt.Errorf("%d log records spooled, %s %s is %s", n, w.conf.Network, w.conf.Address, w.state)
+	}
+	return nil
+}
+
+// Close sends the spooled records, making a last connection attempt if
+// needed, and stops the goroutine. Records left in a disk spool are sent
+// by the next writer using it.
+func (w *NetWriter) Close() error {
+	w.once.Do(func() {
+		w.mu.Lock()
+		w.closing = true
+		w.mu.Unlock()
+		close(w.stop)
+		<-w.done
+
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		var errs []error
+		if _, ok := w.spool.(*memSpool); ok && w.spool.len() > 0 {
+			w.dropped.Add(uint64(w.spool.len()))
+			errs = append(errs, fmt.Errorf("%d log records not sent to %s %s", w.spool.len(), w.conf.Network, w.conf.Address))
+		}
+		errs = append(errs, w.spool.close())
+		w.err = errors.Join(errs...)
+	})
+	return w.err
+}
+
+func (w *NetWriter) setState(state NetState, err error) {
+	w.mu.Lock()
+	changed := w.state != state
+	w.state = state
+	w.changed.Broadcast()
+	w.mu.Unlock()
+	if changed && w.conf.OnState != nil {
+		w.conf.OnState(state, err)
+	}
+}
+
+// netConn is a connection to the collector, dead is closed when a stream
+// connection is closed by the collector.
+type netConn struct {
+	net.Conn
+	dead chan struct{}
+}
+
+func (w *NetWriter) dial() (*netConn, error) {
+	d := &net.Dialer{Timeout: w.conf.DialTimeout}
+	var conn net.Conn
+	var err error
+	if w.tls != nil {
+		conn, err = (&tls.Dialer{NetDialer: d, Config: w.tls}).Dial(w.conf.Network, w.conf.Address)
+	} else {
+		conn, err = d.Dial(w.conf.Network, w.conf.Address)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c := &netConn{Conn: conn}
+	if !w.datagram {
+		// collectors do not reply, a read only returns when the connection is closed
+		c.dead = make(chan struct{})
+		go func() {
+			var buf [512]byte
+			for {
+				if _, err := conn.Read(buf[:]); err != nil {
+					close(c.dead)
+					return
+				}
+			}
+		}()
+	}
+	return c, nil
+}
+
+func (w *NetWriter) run() {
+	defer close(w.done)
+
+	var conn *netConn
+	backoff := w.conf.MinBackoff
+	var buf []byte
+	for {
+		w.mu.Lock()
+		pending, closing := w.spool.len(), w.closing
+		w.mu.Unlock()
+
+		if conn == nil {
+			var err error
+			if conn, err = w.dial(); err != nil {
+				w.setState(NetDisconnected, err)
+				if closing {
+					break
+				}
+				select {
+				case <-time.After(backoff):
+				case <-w.stop:
+				}
+				backoff = min(backoff*2, w.conf.MaxBackoff)
+				continue
+			}
+			backoff = w.conf.MinBackoff
+			w.setState(NetConnected, nil)
+		}
+
+		if pending == 0 {
+			if closing {
+				break
+			}
+			select {
+			case <-w.wake:
+			case <-w.stop:
+			case <-conn.dead:
+				_ = conn.Close()
+				conn = nil
+				w.setState(NetDisconnected, errors.New("connection closed by the collector"))
+			}
+			continue
+		}
+
+		var err error
+		if buf, err = w.send(conn, buf); err != nil {
+			_ = conn.Close()
+			conn = nil
+			w.setState(NetDisconnected, err)
+			if closing {
+				break
+			}
+		}
+	}
+
+	if conn != nil {
+		_ = conn.Close()
+	}
+	w.setState(NetClosed, nil)
+}
+
+// send writes a batch of spooled records to conn and removes the written ones
+// from the spool, buf is reused for the framed records.
+func (w *NetWriter) send(conn *netConn, buf []byte) ([]byte, error) {
+	select {
+	case <-conn.dead:
+		return buf, errors.New("connection closed by the collector")
+	default:
+	}
+
+	w.mu.Lock()
+	records, err := w.spool.peek(64 * 1024)
+	evicted := w.evicted
+	w.mu.Unlock()
+	if err != nil {
+		return buf, err
+	}
+
+	_ = conn.SetWriteDeadline(time.Now().Add(w.conf.WriteTimeout))
+	sent := len(records)
+	if w.datagram {
+	records:
+		for i, rec := range records {
+			var datagrams [][]byte
+			if w.framing.datagrams != nil {
+				if datagrams = w.framing.datagrams(rec); len(datagrams) == 0 {
+					w.dropped.Add(1)
+				}
+			} else {
+				buf = w.framing.frame(buf[:0], rec)
+				datagrams = [][]byte{buf}
+			}
+			for _, d := range datagrams {
+				if _, err = conn.Write(d); err != nil {
+					sent = i
+					break records
+				}
+			}
+		}
+	} else {
+		buf = buf[:0]
+		for _, rec := range records {
+			buf = w.framing.frame(buf, rec)
+		}
+		if _, err = conn.Write(buf); err != nil {
+			sent = 0
+		}
+	}
+
+	w.mu.Lock()
+	// records evicted since the peek were part of the batch
+	if n := sent - int(w.evicted-evicted); n > 0 {
+		if perr := w.spool.pop(n); perr != nil && err == nil {
+			err = perr
+		}
+	}
+	w.changed.Broadcast()
+	w.mu.Unlock()
+	return buf, err
+}
@@ -0,0 +1,284 @@
+package logger
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// collector accepts stream connections and sends the lines it reads to lines.
+type collector struct {
+	ln    net.Listener
+	lines chan string
+	conns chan net.Conn
+}
+
+func newCollector(t *testing.T, ln net.Listener) *collector {
+	c := &collector{ln: ln, lines: make(chan string, 100), conns: make(chan net.Conn, 10)}
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			c.conns <- conn
+			go func() {
+				s := bufio.NewScanner(conn)
+				for s.Scan() {
+					c.lines <- s.Text()
+				}
+			}()
+		}
+	}()
+	return c
+}
+
+func (c *collector) next(t *testing.T) string {
+	t.Helper()
+	select {
+	case line := <-c.lines:
+		return line
+	case <-time.After(5 * time.Second):
+		t.Fatal("no record received")
+		return ""
+	}
+}
+
+func waitState(t *testing.T, states <-chan NetState, want NetState) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case s := <-states:
+			if s == want {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("state %s not reached", want)
+		}
+	}
+}
+
+func TestLogger_Network(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	c := newCollector(t, ln)
+
+	states := make(chan NetState, 100)
+	l, err := Open(&Config{
+		Mode: ModeNetwork,
+		Network: &NetworkConfig{
+			Address:    ln.Addr().String(),
+			MinBackoff: 10 * time.Millisecond,
+			OnState:    func(state NetState, _ error) { states <- state },
+		},
+	})
+	require.NoError(t, err)
+	defer l.Close()
+
+	l.Info("first", "n", 1)
+	l.Info("second")
+	require.NoError(t, l.Sync())
+	assert.Contains(t, c.next(t), `"msg":"first","n":1`)
+	assert.Contains(t, c.next(t), `"msg":"second"`)
+	waitState(t, states, NetConnected)
+
+	// the collector restarts: records are spooled, then sent on reconnect
+	_ = (<-c.conns).Close()
+	waitState(t, states, NetDisconnected)
+	l.Info("third")
+	waitState(t, states, NetConnected)
+	require.NoError(t, l.Sync())
+	assert.Contains(t, c.next(t), `"msg":"third"`)
+}
+
+func TestNetWriter_Spool(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+
+	w, err := NewNetWriter(NetworkConfig{Address: addr, MinBackoff: 10 * time.Millisecond, MaxBackoff: 20 * time.Millisecond, SpoolSize: 10})
+	require.NoError(t, err)
+	defer w.Close()
+
+	_, _ = w.Write([]byte("aaaa\nbbbb\n"))
+	_, _ = w.Write([]byte("cccc\n"))
+	_, _ = w.Write([]byte("this record is too long\n"))
+	assert.Equal(t, 2, w.Pending())
+	assert.Equal(t, uint64(2), w.Dropped())
+	assert.Eventually(t, func() bool { return w.State() == NetDisconnected }, 5*time.Second, time.Millisecond)
+	assert.ErrorContains(t, w.Sync(), "2 log records spooled, tcp "+addr+" is disconnected")
+
+	ln, err = net.Listen("tcp", addr)
+	require.NoError(t, err)
+	c := newCollector(t, ln)
+	assert.Equal(t, "bbbb", c.next(t))
+	assert.Equal(t, "cccc", c.next(t))
+	require.NoError(t, w.Sync())
+	assert.Equal(t, NetConnected, w.State())
+
+	require.NoError(t, w.Close())
+	assert.Equal(t, NetClosed, w.State())
+	_, _ = w.Write([]byte("closed\n"))
+	assert.Equal(t, uint64(3), w.Dropped())
+}
+
+func TestNetWriter_DiskSpool(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+
+	dir := t.TempDir()
+	conf := NetworkConfig{Address: addr, DialTimeout: 100 * time.Millisecond, MinBackoff: 10 * time.Millisecond, SpoolDir: dir}
+	w, err := NewNetWriter(conf)
+	require.NoError(t, err)
+	for _, rec := range []string{"one\n", "two\n", "three\n"} {
+		_, _ = w.Write([]byte(rec))
+	}
+	require.NoError(t, w.Close())
+
+	// a crash left a partial record at the end of the spool
+	files, err := filepath.Glob(filepath.Join(dir, "*.spool"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	f, err := os.OpenFile(files[0], os.O_WRONLY|os.O_APPEND, 0)
+	require.NoError(t, err)
+	_, _ = f.Write([]byte{0, 0, 0, 9, 'p'})
+	require.NoError(t, f.Close())
+
+	ln, err = net.Listen("tcp", addr)
+	require.NoError(t, err)
+	c := newCollector(t, ln)
+
+	w, err = NewNetWriter(conf)
+	require.NoError(t, err)
+	_, _ = w.Write([]byte("four\n"))
+	for _, want := range []string{"one", "two", "three", "four"} {
+		assert.Equal(t, want, c.next(t))
+	}
+	require.NoError(t, w.Sync())
+	require.NoError(t, w.Close())
+
+	fi, err := os.Stat(files[0])
+	require.NoError(t, err)
+	assert.Zero(t, fi.Size())
+}
+
+func TestNetWriter_Datagram(t *testing.T) {
+	for _, network := range []string{"udp", "unixgram"} {
+		t.Run(network, func(t *testing.T) {
+			addr := "127.0.0.1:0"
+			if network == "unixgram" {
+				addr = filepath.Join(t.TempDir(), "log.sock")
+			}
+			pc, err := net.ListenPacket(network, addr)
+			require.NoError(t, err)
+			defer pc.Close()
+
+			w, err := NewNetWriter(NetworkConfig{Network: network, Address: pc.LocalAddr().String()})
+			require.NoError(t, err)
+			defer w.Close()
+
+			_, _ = w.Write([]byte("{\"msg\":\"a\"}\n{\"msg\":\"b\"}\n"))
+			require.NoError(t, w.Sync())
+
+			buf := make([]byte, 1024)
+			for _, want := range []string{`{"msg":"a"}`, `{"msg":"b"}`} {
+				_ = pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+				n, _, err := pc.ReadFrom(buf)
+				require.NoError(t, err)
+				assert.Equal(t, want, string(buf[:n]))
+			}
+		})
+	}
+}
+
+func TestNetWriter_Unix(t *testing.T) {
+	ln, err := net.Listen("unix", filepath.Join(t.TempDir(), "log.sock"))
+	require.NoError(t, err)
+	c := newCollector(t, ln)
+
+	w, err := NewNetWriter(NetworkConfig{Network: "unix", Address: ln.Addr().String()})
+	require.NoError(t, err)
+	defer w.Close()
+
+	_, _ = w.Write([]byte("hello\n"))
+	assert.Equal(t, "hello", c.next(t))
+}
+
+func TestNetWriter_TLS(t *testing.T) {
+	dir := t.TempDir()
+	cert := writeTestCert(t, dir)
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	require.NoError(t, err)
+	c := newCollector(t, ln)
+
+	w, err := NewNetWriter(NetworkConfig{
+		Address: ln.Addr().String(),
+		TLS:     &TLSConfig{CAFile: filepath.Join(dir, "cert.pem"), ServerName: "collector.test"},
+	})
+	require.NoError(t, err)
+	defer w.Close()
+
+	_, _ = w.Write([]byte("secure\n"))
+	assert.Equal(t, "secure", c.next(t))
+
+	_, err = NewNetWriter(NetworkConfig{Network: "udp", Address: ln.Addr().String(), TLS: &TLSConfig{}})
+	assert.ErrorContains(t, err, `tls requires a tcp network, got "udp"`)
+	_, err = NewNetWriter(NetworkConfig{Address: ln.Addr().String(), TLS: &TLSConfig{CAFile: filepath.Join(dir, "missing.pem")}})
+	assert.ErrorContains(t, err, "read tls ca_file")
+}
+
+// writeTestCert writes a self-signed certificate for collector.test to dir/cert.pem.
+func writeTestCert(t *testing.T, dir string) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		DNSNames:              []string{"collector.test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "cert.pem"), certPEM, 0o644))
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	cert, err := tls.X509KeyPair(certPEM, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+	require.NoError(t, err)
+	return cert
+}
+
+func TestConfig_ValidateNetwork(t *testing.T) {
+	err := (&Config{Mode: ModeNetwork}).Validate()
+	assert.ErrorContains(t, err, "network mode requires a network address")
+
+	err = (&Config{Mode: ModeNetwork, Network: &NetworkConfig{Network: "sctp", Address: "x"}}).Validate()
+	assert.ErrorContains(t, err, `unknown network "sctp"`)
+
+	err = (&Config{Sinks: []SinkConfig{{Mode: ModeNetwork, Network: &NetworkConfig{Network: "udp", Address: "x", TLS: &TLSConfig{}, SpoolSize: -1}}}}).Validate()
+	assert.ErrorContains(t, err, `sinks[0]: tls requires a tcp network, got "udp"`)
+	assert.ErrorContains(t, err, "sinks[0]: network timeouts, backoffs and spool_size cannot be negative")
+}
@@ -0,0 +1,613 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/goapt/logger/sloghttp"
+)
+
+// OTLP/HTTP encodings.
+const (
+	OTLPProtobuf = "http/protobuf"
+	OTLPJSON     = "http/json"
+)
+
+// OTLPScope is the instrumentation scope of the exported log records.
+const OTLPScope = "github.com/goapt/logger"
+
+type OTLPConfig struct {
+	// Endpoint is the URL of the logs endpoint, default $OTEL_EXPORTER_OTLP_LOGS_ENDPOINT,
+	// $OTEL_EXPORTER_OTLP_ENDPOINT/v1/logs or http://localhost:4318/v1/logs.
+	Endpoint      string            `json:"endpoint" yaml:"endpoint"`
+	Protocol      string            `json:"protocol" yaml:"protocol"`             // http/protobuf or http/json, default http/protobuf
+	Headers       map[string]string `json:"headers" yaml:"headers"`               // e.g. an authorization header
+	Resource      map[string]any    `json:"resource" yaml:"resource"`             // resource attributes, service.name defaults to unknown_service:<program>
+	BatchSize     int               `json:"batch_size" yaml:"batch_size"`         // default 512 records
+	FlushInterval time.Duration     `json:"flush_interval" yaml:"flush_interval"` // default 1s
+	QueueSize     int               `json:"queue_size" yaml:"queue_size"`         // default 2048 records, the newest are dropped beyond it
+	Timeout       time.Duration     `json:"timeout" yaml:"timeout"`               // of an export request, default 10s
+	MaxRetries    int               `json:"max_retries" yaml:"max_retries"`       // of a failed export, default 5
+	DisableRetry  bool              `json:"disable_retry" yaml:"disable_retry"`   // never retry a failed export
+	RetryBackoff  time.Duration     `json:"retry_backoff" yaml:"retry_backoff"`   // first delay between retries, doubled up to 30s, default 500ms
+
+	Client *http.Client `json:"-" yaml:"-"` // default a client with Timeout
+}
+
+func (c *OTLPConfig) validate() []error {
+	var errs []error
+	if c.Endpoint != "" {
+		if u, err := url.Parse(c.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("otlp endpoint must be an http or https URL, got %q", c.Endpoint))
+		}
+	}
+	switch c.Protocol {
+	case "", OTLPProtobuf, OTLPJSON:
+	default:
+		errs = append(errs, fmt.Errorf("unknown otlp protocol %q", c.Protocol))
+	}
+	if c.BatchSize < 0 || c.FlushInterval < 0 || c.QueueSize < 0 || c.Timeout < 0 || c.MaxRetries < 0 || c.RetryBackoff < 0 {
+		errs = append(errs, errors.New("otlp batch_size, flush_interval, queue_size, timeout, max_retries and retry_backoff cannot be negative"))
+	}
+	return errs
+}
+
+// OTLPHandler exports records to an OpenTelemetry collector over OTLP/HTTP.
+// Records are converted to OTLP log records, with the severity number and
+// text of their level, the message as body, the attrs with group keys joined
+// by dots, and the trace and span IDs of the span in their context. They are
+// queued and exported in batches by a background goroutine, failed exports
+// are retried on network errors and on the 429, 502, 503 and 504 statuses.
+// Shutdown cancels the export in flight and the retries.
+type OTLPHandler struct {
+	state  *otlpState
+	attrs  []slog.Attr // flattened attrs of the logger
+	prefix string      // dotted prefix of the open groups
+}
+
+type otlpState struct {
+	conf     OTLPConfig
+	opts     slog.HandlerOptions
+	resource []slog.Attr
+
+	queue   chan otlpRecord
+	syncCh  chan chan error
+	done    chan struct{}
+	ctx     context.Context // of the export requests, cancelled by Shutdown
+	cancel  context.CancelFunc
+	mu      sync.RWMutex // held for writing while closing the queue
+	closed  bool
+	dropped atomic.Uint64
+	err     error // last export error, only used by the goroutine until done
+}
+
+// otlpRecord is a record converted to an OTLP log record.
+type otlpRecord struct {
+	time         time.Time
+	observed     time.Time
+	severity     int
+	severityText string
+	body         string
+	attrs        []slog.Attr // flat, with string, bool, int64, float64 or []byte values
+	traceID      trace.TraceID
+	spanID       trace.SpanID
+	flags        trace.TraceFlags
+}
+
+// NewOTLPHandler creates an OTLPHandler and starts its export goroutine,
+// Close it to export the queued records.
+func NewOTLPHandler(conf OTLPConfig, opts *slog.HandlerOptions) (*OTLPHandler, error) {
+	if conf.Endpoint == "" {
+		conf.Endpoint = "http://localhost:4318/v1/logs"
+		if v := os.Getenv("OTEL_EXPORTER_OTLP_LOGS_ENDPOINT"); v != "" {
+			conf.Endpoint = v
+		} else if v := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); v != "" {
+			conf.Endpoint = strings.TrimSuffix(v, "/") + "/v1/logs"
+		}
+	}
+	if conf.Protocol == "" {
+		conf.Protocol = OTLPProtobuf
+	}
+	if conf.Protocol != OTLPProtobuf && conf.Protocol != OTLPJSON {
+		return nil, fmt.Errorf("unknown otlp protocol %q", conf.Protocol)
+	}
+	if conf.BatchSize <= 0 {
+		conf.BatchSize = 512
+	}
+	if conf.FlushInterval <= 0 {
+		conf.FlushInterval = time.Second
+	}
+	if conf.QueueSize <= 0 {
+		conf.QueueSize = 2048
+	}
+	if conf.Timeout <= 0 {
+		conf.Timeout = 10 * time.Second
+	}
+	if conf.MaxRetries <= 0 {
+		conf.MaxRetries = 5
+	}
+	if conf.DisableRetry {
+		conf.MaxRetries = 0
+	}
+	if conf.RetryBackoff <= 0 {
+		conf.RetryBackoff = 500 * time.Millisecond
+	}
+	if conf.Client == nil {
+		conf.Client = &http.Client{Timeout: conf.Timeout}
+	}
+
+	resource := conf.Resource
+	if _, ok := resource["service.name"]; !ok {
+		resource = maps.Clone(resource)
+		if resource == nil {
+			resource = make(map[string]any)
+		}
+		resource["service.name"] = "unknown_service:" + filepath.Base(os.Args[0])
+	}
+
+	s := &otlpState{
+		conf:   conf,
+		queue:  make(chan otlpRecord, conf.QueueSize),
+		syncCh: make(chan chan error),
+		done:   make(chan struct{}),
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	if opts != nil {
+		s.opts = *opts
+	}
+	for _, key := range slices.Sorted(maps.Keys(resource)) {
+		s.resource = appendOTLPAttr(s.resource, "", slog.Any(key, resource[key]))
+	}
+	go s.run()
+	return &OTLPHandler{state: s}, nil
+}
+
+func (h *OTLPHandler) Enabled(_ context.Context, level slog.Level) bool {
+	min := slog.LevelInfo
+	if h.state.opts.Level != nil {
+		min = h.state.opts.Level.Level()
+	}
+	return level >= min
+}
+
+func (h *OTLPHandler) Handle(ctx context.Context, r slog.Record) error {
+	s := h.state
+	rec := otlpRecord{
+		time:         r.Time,
+		observed:     time.Now(),
+		severity:     otlpSeverity(r.Level),
+		severityText: r.Level.String(),
+		body:         r.Message,
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		rec.traceID, rec.spanID, rec.flags = sc.TraceID(), sc.SpanID(), sc.TraceFlags()
+	}
+
+	if s.opts.AddSource && r.PC != 0 {
+		src := recordSource(r)
+		rec.attrs = append(rec.attrs,
+			slog.String("code.filepath", src.File),
+			slog.Int("code.lineno", src.Line),
+			slog.String("code.function", src.Function),
+		)
+	}
+	attrs := slices.Clip(h.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = appendFlatAttr(attrs, h.prefix, a)
+		return true
+	})
+	for _, a := range attrs {
+		if rec.traceID.IsValid() && (a.Key == sloghttp.TraceIDKey || a.Key == sloghttp.SpanIDKey) {
+			// added by the context handler, the log record has them
+			continue
+		}
+		if s.opts.ReplaceAttr != nil {
+			if a = s.opts.ReplaceAttr(nil, a); a.Key == "" {
+				continue
+			}
+		}
+		rec.attrs = appendOTLPAttr(rec.attrs, "", a)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		s.dropped.Add(1)
+		return nil
+	}
+	select {
+	case s.queue <- rec:
+	default:
+		s.dropped.Add(1)
+	}
+	return nil
+}
+
+func (h *OTLPHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	flat := slices.Clip(h.attrs)
+	for _, a := range attrs {
+		flat = appendFlatAttr(flat, h.prefix, a)
+	}
+	return &OTLPHandler{state: h.state, attrs: flat, prefix: h.prefix}
+}
+
+func (h *OTLPHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &OTLPHandler{state: h.state, attrs: h.attrs, prefix: h.prefix + name + "."}
+}
+
+// Dropped returns the number of records dropped because the queue was full,
+// the handler closed or their export failed.
+func (h *OTLPHandler) Dropped() uint64 { return h.state.dropped.Load() }
+
+// Sync exports the records queued so far and returns the error of the export.
+func (h *OTLPHandler) Sync() error {
+	s := h.state
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return nil
+	}
+	ch := make(chan error, 1)
+	s.syncCh <- ch
+	return <-ch
+}
+
+// Close exports the queued records and stops the goroutine, records handled
+// afterward are dropped. It returns the error of the last failed export.
+func (h *OTLPHandler) Close() error {
+	s := h.state
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	s.mu.Unlock()
+	<-s.done
+	return s.err
+}
+
+// Shutdown is Close bounded by ctx: once ctx is done, the export in flight
+// and the retries are cancelled, the records left are dropped and ctx.Err()
+// is returned.
+func (h *OTLPHandler) Shutdown(ctx context.Context) error {
+	stop := context.AfterFunc(ctx, h.state.cancel)
+	defer stop()
+	err := h.Close()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// cancel cancels the export in flight and the retries.
+func (h *OTLPHandler) cancel() { h.state.cancel() }
+
+func (s *otlpState) run() {
+	defer close(s.done)
+	defer s.cancel()
+	ticker := time.NewTicker(s.conf.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]otlpRecord, 0, s.conf.BatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err := s.export(batch)
+		if err != nil {
+			s.dropped.Add(uint64(len(batch)))
+			s.err = err
+		}
+		clear(batch)
+		batch = batch[:0]
+		return err
+	}
+
+	for {
+		select {
+		case rec, ok := <-s.queue:
+			if !ok {
+				_ = flush()
+				return
+			}
+			if batch = append(batch, rec); len(batch) >= s.conf.BatchSize {
+				_ = flush()
+			}
+		case <-ticker.C:
+			_ = flush()
+		case ch := <-s.syncCh:
+			var errs []error
+			for n := len(s.queue); n > 0; n-- {
+				if batch = append(batch, <-s.queue); len(batch) >= s.conf.BatchSize {
+					errs = append(errs, flush())
+				}
+			}
+			ch <- errors.Join(append(errs, flush())...)
+		}
+	}
+}
+
+// export posts the batch, retrying the retryable failures.
+func (s *otlpState) export(batch []otlpRecord) error {
+	var body []byte
+	contentType := "application/x-protobuf"
+	if s.conf.Protocol == OTLPJSON {
+		contentType = "application/json"
+		body = encodeOTLPJSON(s.resource, batch)
+	} else {
+		body = encodeOTLPProto(s.resource, batch)
+	}
+
+	backoff := s.conf.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		retry, wait, err := s.post(body, contentType)
+		if err == nil || !retry || attempt >= s.conf.MaxRetries {
+			return err
+		}
+		if wait <= 0 {
+			wait = backoff
+			backoff = min(backoff*2, otlpMaxBackoff)
+		}
+		t := time.NewTimer(min(wait, otlpMaxBackoff))
+		select {
+		case <-t.C:
+		case <-s.ctx.Done():
+			t.Stop()
+			return err
+		}
+	}
+}
+
+// otlpMaxBackoff bounds the delay between retries, including the one asked
+// by the collector with Retry-After.
+const otlpMaxBackoff = 30 * time.Second
+
+// post sends an export request and reports whether a failure is retryable,
+// after the delay asked by the collector if any.
+func (s *otlpState) post(body []byte, contentType string) (retry bool, wait time.Duration, err error) {
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodPost, s.conf.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return false, 0, fmt.Errorf("otlp export: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	for k, v := range s.conf.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.conf.Client.Do(req)
+	if err != nil {
+		return true, 0, fmt.Errorf("otlp export: %w", err)
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, 0, nil
+	}
+
+	err = fmt.Errorf("otlp export: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		if secs, perr := strconv.Atoi(resp.Header.Get("Retry-After")); perr == nil && secs >= 0 {
+			wait = time.Duration(secs) * time.Second
+		}
+		return true, wait, err
+	}
+	return false, 0, err
+}
+
+// otlpSeverity maps a level to an OTLP severity number: DEBUG is 5, INFO 9,
+// WARN 13 and ERROR 17, as in the OpenTelemetry slog bridge.
+func otlpSeverity(level slog.Level) int {
+	return min(max(int(level)+9, 1), 24)
+}
+
+// appendOTLPAttr appends a, resolved and flattened, with its value converted
+// to a type of OTLP AnyValue.
+func appendOTLPAttr(attrs []slog.Attr, prefix string, a slog.Attr) []slog.Attr {
+	for _, fa := range appendFlatAttr(nil, prefix, a) {
+		v := fa.Value
+		switch v.Kind() {
+		case slog.KindString, slog.KindBool, slog.KindInt64:
+		case slog.KindUint64:
+			if v.Uint64() <= math.MaxInt64 {
+				v = slog.Int64Value(int64(v.Uint64()))
+			} else {
+				v = slog.StringValue(v.String())
+			}
+		case slog.KindFloat64:
+			if math.IsNaN(v.Float64()) || math.IsInf(v.Float64(), 0) {
+				v = slog.StringValue(v.String())
+			}
+		case slog.KindDuration:
+			v = slog.Int64Value(int64(v.Duration()))
+		case slog.KindTime:
+			v = slog.StringValue(v.Time().Format(time.RFC3339Nano))
+		default:
+			if b, ok := v.Any().([]byte); ok {
+				v = slog.AnyValue(b)
+			} else {
+				v = slog.StringValue(v.String())
+			}
+		}
+		attrs = append(attrs, slog.Attr{Key: fa.Key, Value: v})
+	}
+	return attrs
+}
+
+// encodeOTLPJSON encodes an ExportLogsServiceRequest in the OTLP/JSON
+// encoding: camelCase field names, 64-bit integers as strings and hex trace
+// and span IDs.
+func encodeOTLPJSON(resource []slog.Attr, batch []otlpRecord) []byte {
+	buf := make([]byte, 0, 256*len(batch))
+	buf = append(buf, `{"resourceLogs":[{"resource":{"attributes":`...)
+	buf = appendOTLPJSONAttrs(buf, resource)
+	buf = append(buf, `},"scopeLogs":[{"scope":{"name":`...)
+	buf = appendJSONString(buf, OTLPScope)
+	buf = append(buf, `},"logRecords":[`...)
+	for i, rec := range batch {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, '{')
+		if !rec.time.IsZero() {
+			buf = append(buf, `"timeUnixNano":"`...)
+			buf = strconv.AppendInt(buf, rec.time.UnixNano(), 10)
+			buf = append(buf, `",`...)
+		}
+		buf = append(buf, `"observedTimeUnixNano":"`...)
+		buf = strconv.AppendInt(buf, rec.observed.UnixNano(), 10)
+		buf = append(buf, `","severityNumber":`...)
+		buf = strconv.AppendInt(buf, int64(rec.severity), 10)
+		buf = append(buf, `,"severityText":`...)
+		buf = appendJSONString(buf, rec.severityText)
+		buf = append(buf, `,"body":{"stringValue":`...)
+		buf = appendJSONString(buf, rec.body)
+		buf = append(buf, `},"attributes":`...)
+		buf = appendOTLPJSONAttrs(buf, rec.attrs)
+		if rec.traceID.IsValid() {
+			buf = append(buf, `,"traceId":"`...)
+			buf = append(buf, rec.traceID.String()...)
+			buf = append(buf, `","spanId":"`...)
+			buf = append(buf, rec.spanID.String()...)
+			buf = append(buf, `","flags":`...)
+			buf = strconv.AppendUint(buf, uint64(rec.flags), 10)
+		}
+		buf = append(buf, '}')
+	}
+	return append(buf, `]}]}]}`...)
+}
+
+func appendOTLPJSONAttrs(buf []byte, attrs []slog.Attr) []byte {
+	buf = append(buf, '[')
+	for i, a := range attrs {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, `{"key":`...)
+		buf = appendJSONString(buf, a.Key)
+		buf = append(buf, `,"value":{`...)
+		switch v := a.Value; v.Kind() {
+		case slog.KindString:
+			buf = append(buf, `"stringValue":`...)
+			buf = appendJSONString(buf, v.String())
+		case slog.KindBool:
+			buf = append(buf, `"boolValue":`...)
+			buf = strconv.AppendBool(buf, v.Bool())
+		case slog.KindInt64:
+			buf = append(buf, `"intValue":"`...)
+			buf = strconv.AppendInt(buf, v.Int64(), 10)
+			buf = append(buf, '"')
+		case slog.KindFloat64:
+			buf = append(buf, `"doubleValue":`...)
+			buf = strconv.AppendFloat(buf, v.Float64(), 'g', -1, 64)
+		default:
+			b, _ := json.Marshal(v.Any()) // []byte, base64
+			buf = append(buf, `"bytesValue":`...)
+			buf = append(buf, b...)
+		}
+		buf = append(buf, '}', '}')
+	}
+	return append(buf, ']')
+}
+
+// encodeOTLPProto encodes an ExportLogsServiceRequest in the protobuf wire
+// format of opentelemetry-proto.
+func encodeOTLPProto(resource []slog.Attr, batch []otlpRecord) []byte {
+	var scope, msg []byte
+	scope = protoAppendBytes(scope, 1, protoAppendBytes(nil, 1, []byte(OTLPScope))) // InstrumentationScope.name
+	for _, rec := range batch {
+		msg = msg[:0]
+		if !rec.time.IsZero() {
+			msg = protoAppendFixed64(msg, 1, uint64(rec.time.UnixNano()))
+		}
+		msg = protoAppendVarint(msg, 2, uint64(rec.severity))
+		msg = protoAppendBytes(msg, 3, []byte(rec.severityText))
+		msg = protoAppendBytes(msg, 5, protoAppendBytes(nil, 1, []byte(rec.body))) // AnyValue.string_value
+		msg = protoAppendAttrs(msg, 6, rec.attrs)
+		if rec.traceID.IsValid() {
+			msg = protoAppendFixed32(msg, 8, uint32(rec.flags))
+			msg = protoAppendBytes(msg, 9, rec.traceID[:])
+			msg = protoAppendBytes(msg, 10, rec.spanID[:])
+		}
+		msg = protoAppendFixed64(msg, 11, uint64(rec.observed.UnixNano()))
+		scope = protoAppendBytes(scope, 2, msg) // ScopeLogs.log_records
+	}
+
+	var rl []byte
+	rl = protoAppendBytes(rl, 1, protoAppendAttrs(nil, 1, resource)) // ResourceLogs.resource
+	rl = protoAppendBytes(rl, 2, scope)                              // ResourceLogs.scope_logs
+	return protoAppendBytes(nil, 1, rl)                              // ExportLogsServiceRequest.resource_logs
+}
+
+// protoAppendAttrs appends attrs as repeated KeyValue fields.
+func protoAppendAttrs(buf []byte, field int, attrs []slog.Attr) []byte {
+	var kv, v []byte
+	for _, a := range attrs {
+		v = v[:0]
+		switch a.Value.Kind() {
+		case slog.KindString:
+			v = protoAppendBytes(v, 1, []byte(a.Value.String()))
+		case slog.KindBool:
+			var b uint64
+			if a.Value.Bool() {
+				b = 1
+			}
+			v = protoAppendVarint(v, 2, b)
+		case slog.KindInt64:
+			v = protoAppendVarint(v, 3, uint64(a.Value.Int64()))
+		case slog.KindFloat64:
+			v = protoAppendFixed64(v, 4, math.Float64bits(a.Value.Float64()))
+		default:
+			b, _ := a.Value.Any().([]byte)
+			v = protoAppendBytes(v, 7, b)
+		}
+		kv = protoAppendBytes(kv[:0], 1, []byte(a.Key))
+		kv = protoAppendBytes(kv, 2, v)
+		buf = protoAppendBytes(buf, field, kv)
+	}
+	return buf
+}
+
+func protoAppendVarint(buf []byte, field int, v uint64) []byte {
+	buf = binary.AppendUvarint(buf, uint64(field)<<3)
+	return binary.AppendUvarint(buf, v)
+}
+
+func protoAppendFixed64(buf []byte, field int, v uint64) []byte {
+	buf = binary.AppendUvarint(buf, uint64(field)<<3|1)
+	return binary.LittleEndian.AppendUint64(buf, v)
+}
+
+func protoAppendFixed32(buf []byte, field int, v uint32) []byte {
+	buf = binary.AppendUvarint(buf, uint64(field)<<3|5)
+	return binary.LittleEndian.AppendUint32(buf, v)
+}
+
+func protoAppendBytes(buf []byte, field int, b []byte) []byte {
+	buf = binary.AppendUvarint(buf, uint64(field)<<3|2)
+	buf = binary.AppendUvarint(buf, uint64(len(b)))
+	return append(buf, b...)
+}
@@ -0,0 +1,383 @@
+package logger
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// otlpCollector is a local OTLP/HTTP collector answering with the statuses
+// of its queue, then 200.
+type otlpCollector struct {
+	*httptest.Server
+	mu       sync.Mutex
+	bodies   [][]byte
+	headers  []http.Header
+	statuses []int
+}
+
+func newOTLPCollector(t *testing.T, statuses ...int) *otlpCollector {
+	c := &otlpCollector{statuses: statuses}
+	c.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.bodies = append(c.bodies, body)
+		c.headers = append(c.headers, r.Header.Clone())
+		if len(c.statuses) > 0 {
+			status := c.statuses[0]
+			c.statuses = c.statuses[1:]
+			http.Error(w, "unavailable", status)
+		}
+	}))
+	t.Cleanup(c.Close)
+	return c
+}
+
+func (c *otlpCollector) requests() ([][]byte, []http.Header) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.bodies, c.headers
+}
+
+func testSpanContext() context.Context {
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	return trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+}
+
+func TestOTLPHandler_JSON(t *testing.T) {
+	c := newOTLPCollector(t)
+	h, err := NewOTLPHandler(OTLPConfig{
+		Endpoint: c.URL + "/v1/logs",
+		Protocol: OTLPJSON,
+		Headers:  map[string]string{"Authorization": "Bearer secret"},
+		Resource: map[string]any{"service.name": "billing", "service.instance": 3},
+	}, nil)
+	require.NoError(t, err)
+	defer h.Close()
+
+	l := slog.New(h)
+	l.With("id", 7).WarnContext(testSpanContext(), "order failed",
+		slog.Group("request", "method", "GET", "ok", false),
+		"latency", 1.5, "raw", []byte("hi"), "nan", math.NaN(), "trace_id", "dup",
+	)
+	require.NoError(t, h.Sync())
+
+	bodies, headers := c.requests()
+	require.Len(t, bodies, 1)
+	assert.Equal(t, "application/json", headers[0].Get("Content-Type"))
+	assert.Equal(t, "Bearer secret", headers[0].Get("Authorization"))
+
+	var req struct {
+		ResourceLogs []struct {
+			Resource struct {
+				Attributes []map[string]any `json:"attributes"`
+			} `json:"resource"`
+			ScopeLogs []struct {
+				Scope      map[string]any   `json:"scope"`
+				LogRecords []map[string]any `json:"logRecords"`
+			} `json:"scopeLogs"`
+		} `json:"resourceLogs"`
+	}
+	require.NoError(t, json.Unmarshal(bodies[0], &req))
+	require.Len(t, req.ResourceLogs, 1)
+	assert.Equal(t, []map[string]any{
+		{"key": "service.instance", "value": map[string]any{"intValue": "3"}},
+		{"key": "service.name", "value": map[string]any{"stringValue": "billing"}},
+	}, req.ResourceLogs[0].Resource.Attributes)
+	require.Len(t, req.ResourceLogs[0].ScopeLogs, 1)
+	assert.Equal(t, OTLPScope, req.ResourceLogs[0].ScopeLogs[0].Scope["name"])
+
+	rec := req.ResourceLogs[0].ScopeLogs[0].LogRecords[0]
+	assert.NotEmpty(t, rec["timeUnixNano"])
+	assert.NotEmpty(t, rec["observedTimeUnixNano"])
+	delete(rec, "timeUnixNano")
+	delete(rec, "observedTimeUnixNano")
+	assert.Equal(t, map[string]any{
+		"severityNumber": float64(13),
+		"severityText":   "WARN",
+		"body":           map[string]any{"stringValue": "order failed"},
+		"attributes": []any{
+			map[string]any{"key": "id", "value": map[string]any{"intValue": "7"}},
+			map[string]any{"key": "request.method", "value": map[string]any{"stringValue": "GET"}},
+			map[string]any{"key": "request.ok", "value": map[string]any{"boolValue": false}},
+			map[string]any{"key": "latency", "value": map[string]any{"doubleValue": 1.5}},
+			map[string]any{"key": "raw", "value": map[string]any{"bytesValue": "aGk="}},
+			map[string]any{"key": "nan", "value": map[string]any{"stringValue": "NaN"}},
+		},
+		"traceId": "4bf92f3577b34da6a3ce929d0e0e4736",
+		"spanId":  "00f067aa0ba902b7",
+		"flags":   float64(1),
+	}, rec)
+}
+
+func TestOTLPHandler_Protobuf(t *testing.T) {
+	c := newOTLPCollector(t)
+	h, err := NewOTLPHandler(OTLPConfig{Endpoint: c.URL}, &slog.HandlerOptions{Level: slog.LevelDebug})
+	require.NoError(t, err)
+	defer h.Close()
+
+	slog.New(h).WithGroup("job").DebugContext(testSpanContext(), "retrying", "attempt", 2, "ok", true, "latency", 0.25)
+	require.NoError(t, h.Sync())
+
+	bodies, headers := c.requests()
+	require.Len(t, bodies, 1)
+	assert.Equal(t, "application/x-protobuf", headers[0].Get("Content-Type"))
+
+	rl := protoFields(t, protoFields(t, bodies[0])[1][0].([]byte))
+	resource := protoFields(t, rl[1][0].([]byte))
+	kv := protoFields(t, resource[1][0].([]byte))
+	assert.Equal(t, "service.name", string(kv[1][0].([]byte)))
+
+	sl := protoFields(t, rl[2][0].([]byte))
+	assert.Equal(t, OTLPScope, string(protoFields(t, sl[1][0].([]byte))[1][0].([]byte)))
+	require.Len(t, sl[2], 1)
+	rec := protoFields(t, sl[2][0].([]byte))
+	assert.InDelta(t, time.Now().UnixNano(), int64(rec[1][0].(uint64)), float64(5*time.Second))
+	assert.Equal(t, uint64(5), rec[2][0])
+	assert.Equal(t, "DEBUG", string(rec[3][0].([]byte)))
+	assert.Equal(t, "retrying", string(protoFields(t, rec[5][0].([]byte))[1][0].([]byte)))
+	assert.Equal(t, uint64(1), rec[8][0])
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", trace.TraceID(rec[9][0].([]byte)).String())
+	assert.Equal(t, "00f067aa0ba902b7", trace.SpanID(rec[10][0].([]byte)).String())
+	assert.NotZero(t, rec[11][0])
+
+	require.Len(t, rec[6], 3)
+	attrs := map[string]map[int][]any{}
+	for _, a := range rec[6] {
+		kv := protoFields(t, a.([]byte))
+		attrs[string(kv[1][0].([]byte))] = protoFields(t, kv[2][0].([]byte))
+	}
+	assert.Equal(t, map[int][]any{3: {uint64(2)}}, attrs["job.attempt"])
+	assert.Equal(t, map[int][]any{2: {uint64(1)}}, attrs["job.ok"])
+	assert.Equal(t, map[int][]any{4: {math.Float64bits(0.25)}}, attrs["job.latency"])
+}
+
+// protoFields decodes a protobuf message into its fields by number: uint64
+// for varint and fixed values, []byte for length-delimited ones.
+func protoFields(t *testing.T, msg []byte) map[int][]any {
+	fields := map[int][]any{}
+	for len(msg) > 0 {
+		tag, n := binary.Uvarint(msg)
+		require.Positive(t, n)
+		msg = msg[n:]
+		var v any
+		switch tag & 7 {
+		case 0:
+			v, n = binary.Uvarint(msg)
+			require.Positive(t, n)
+		case 1:
+			v, n = binary.LittleEndian.Uint64(msg), 8
+		case 2:
+			size, m := binary.Uvarint(msg)
+			require.Positive(t, m)
+			v, n = msg[m:m+int(size)], m+int(size)
+		case 5:
+			v, n = uint64(binary.LittleEndian.Uint32(msg)), 4
+		default:
+			t.Fatalf("unexpected wire type %d", tag&7)
+		}
+		msg = msg[n:]
+		fields[int(tag>>3)] = append(fields[int(tag>>3)], v)
+	}
+	return fields
+}
+
+func TestOTLPHandler_Batches(t *testing.T) {
+	c := newOTLPCollector(t)
+	h, err := NewOTLPHandler(OTLPConfig{Endpoint: c.URL, Protocol: OTLPJSON, BatchSize: 2, FlushInterval: time.Hour}, nil)
+	require.NoError(t, err)
+
+	l := slog.New(h)
+	for range 5 {
+		l.Info("hello")
+	}
+	require.NoError(t, h.Close())
+
+	bodies, _ := c.requests()
+	var sizes []int
+	for _, body := range bodies {
+		var req struct {
+			ResourceLogs []struct {
+				ScopeLogs []struct {
+					LogRecords []any `json:"logRecords"`
+				} `json:"scopeLogs"`
+			} `json:"resourceLogs"`
+		}
+		require.NoError(t, json.Unmarshal(body, &req))
+		sizes = append(sizes, len(req.ResourceLogs[0].ScopeLogs[0].LogRecords))
+	}
+	assert.Equal(t, []int{2, 2, 1}, sizes)
+
+	l.Info("closed")
+	assert.Equal(t, uint64(1), h.Dropped())
+}
+
+func TestOTLPHandler_Retry(t *testing.T) {
+	c := newOTLPCollector(t, http.StatusServiceUnavailable, http.StatusTooManyRequests)
+	h, err := NewOTLPHandler(OTLPConfig{Endpoint: c.URL, RetryBackoff: time.Millisecond}, nil)
+	require.NoError(t, err)
+	defer h.Close()
+
+	slog.New(h).Info("hello")
+	require.NoError(t, h.Sync())
+	bodies, _ := c.requests()
+	assert.Len(t, bodies, 3)
+	assert.Zero(t, h.Dropped())
+
+	c.mu.Lock()
+	c.statuses = []int{http.StatusBadRequest}
+	c.mu.Unlock()
+	slog.New(h).Info("rejected")
+	assert.ErrorContains(t, h.Sync(), "otlp export: 400 Bad Request: unavailable")
+	bodies, _ = c.requests()
+	assert.Len(t, bodies, 4, "400 is not retried")
+	assert.Equal(t, uint64(1), h.Dropped())
+
+	c.mu.Lock()
+	c.statuses = []int{http.StatusBadGateway, http.StatusBadGateway}
+	c.mu.Unlock()
+	h2, err := NewOTLPHandler(OTLPConfig{Endpoint: c.URL, MaxRetries: 1, RetryBackoff: time.Millisecond}, nil)
+	require.NoError(t, err)
+	defer h2.Close()
+	slog.New(h2).Info("hello")
+	assert.ErrorContains(t, h2.Sync(), "502 Bad Gateway")
+	assert.Equal(t, uint64(1), h2.Dropped())
+
+	c.mu.Lock()
+	c.statuses = []int{http.StatusBadGateway, http.StatusBadGateway}
+	c.mu.Unlock()
+	h3, err := NewOTLPHandler(OTLPConfig{Endpoint: c.URL, DisableRetry: true}, nil)
+	require.NoError(t, err)
+	defer h3.Close()
+	slog.New(h3).Info("hello")
+	assert.ErrorContains(t, h3.Sync(), "502 Bad Gateway")
+	bodies, _ = c.requests()
+	assert.Len(t, bodies, 7, "retries disabled")
+}
+
+func TestOTLPHandler_Shutdown(t *testing.T) {
+	received := make(chan struct{}, 1)
+	cancelled := make(chan struct{}, 1)
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.ReadAll(r.Body)
+		if requests.Add(1)%2 == 1 {
+			// every other request hangs until cancelled
+			received <- struct{}{}
+			<-r.Context().Done()
+			cancelled <- struct{}{}
+			return
+		}
+		w.Header().Set("Retry-After", "3600")
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		received <- struct{}{}
+	}))
+	defer srv.Close()
+
+	wait := func(ch <-chan struct{}, what string) {
+		select {
+		case <-ch:
+		case <-time.After(10 * time.Second):
+			t.Fatal(what)
+		}
+	}
+	shutdown := func(shutdown func(context.Context) error) {
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() { done <- shutdown(ctx) }()
+		cancel()
+		select {
+		case err := <-done:
+			assert.ErrorIs(t, err, context.Canceled)
+		case <-time.After(10 * time.Second):
+			t.Fatal("shutdown not cancelled")
+		}
+	}
+
+	// the request in flight is cancelled
+	h, err := NewOTLPHandler(OTLPConfig{Endpoint: srv.URL, FlushInterval: time.Millisecond}, nil)
+	require.NoError(t, err)
+	slog.New(h).Info("hello")
+	wait(received, "export not sent")
+	shutdown(h.Shutdown)
+	wait(cancelled, "request not cancelled")
+	assert.Equal(t, uint64(1), h.Dropped())
+
+	// so is the wait before a retry, whatever the Retry-After
+	h, err = NewOTLPHandler(OTLPConfig{Endpoint: srv.URL, FlushInterval: time.Millisecond}, nil)
+	require.NoError(t, err)
+	slog.New(h).Info("hello")
+	wait(received, "export not sent")
+	shutdown(h.Shutdown)
+	assert.Equal(t, uint64(1), h.Dropped())
+	assert.Equal(t, int32(2), requests.Load(), "not retried")
+
+	// and the export of a logger with an otlp sink
+	l, err := Open(&Config{Mode: ModeOTLP, OTLP: &OTLPConfig{Endpoint: srv.URL, FlushInterval: time.Millisecond}})
+	require.NoError(t, err)
+	l.Info("hello")
+	wait(received, "export not sent")
+	shutdown(l.Shutdown)
+	wait(cancelled, "request not cancelled")
+	assert.ErrorIs(t, l.Close(), context.Canceled, "the export failed")
+}
+
+func TestOTLPSeverity(t *testing.T) {
+	for level, want := range map[slog.Level]int{
+		slog.LevelDebug - 8: 1,
+		slog.LevelDebug:     5,
+		slog.LevelInfo:      9,
+		slog.LevelWarn:      13,
+		slog.LevelError:     17,
+		slog.LevelError + 4: 21,
+		slog.LevelError + 8: 24,
+	} {
+		assert.Equal(t, want, otlpSeverity(level), level.String())
+	}
+}
+
+func TestLogger_OTLP(t *testing.T) {
+	c := newOTLPCollector(t)
+	l, err := Open(&Config{
+		Mode:        ModeOTLP,
+		OTLP:        &OTLPConfig{Endpoint: c.URL, Protocol: OTLPJSON},
+		WithContext: true,
+	})
+	require.NoError(t, err)
+	l.WithGroup("req").InfoContext(testSpanContext(), "hello", "user", "bob")
+	require.NoError(t, l.Close())
+
+	bodies, _ := c.requests()
+	require.Len(t, bodies, 1)
+	// the context IDs stay out of the group, the log record has them
+	assert.Contains(t, string(bodies[0]), `"body":{"stringValue":"hello"},"attributes":[{"key":"req.user","value":{"stringValue":"bob"}}],"traceId":"4bf92f3577b34da6a3ce929d0e0e4736"`)
+}
+
+func TestConfig_ValidateOTLP(t *testing.T) {
+	err := (&Config{Mode: ModeOTLP, OTLP: &OTLPConfig{Endpoint: "localhost:4318", Protocol: "grpc", BatchSize: -1, MaxRetries: -1}}).Validate()
+	assert.ErrorContains(t, err, `otlp endpoint must be an http or https URL, got "localhost:4318"`)
+	assert.ErrorContains(t, err, `unknown otlp protocol "grpc"`)
+	assert.ErrorContains(t, err, "otlp batch_size, flush_interval, queue_size, timeout, max_retries and retry_backoff cannot be negative")
+
+	assert.NoError(t, (&Config{Mode: ModeOTLP}).Validate())
+	assert.NoError(t, (&Config{Mode: ModeOTLP, OTLP: &OTLPConfig{Endpoint: "https://otel.example.com/v1/logs", DisableRetry: true}}).Validate())
+}
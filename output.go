@@ -0,0 +1,84 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"time"
+)
+
+// With calls With on the current Default() logger.
+func With(args ...any) *slog.Logger { return Default().With(args...) }
+
+// WithGroup calls WithGroup on the current Default() logger.
+func WithGroup(name string) *slog.Logger { return Default().WithGroup(name) }
+
+// Debug calls Debug on the current Default() logger.
+func Debug(msg string, args ...any) { output(context.Background(), slog.LevelDebug, msg, args...) }
+
+// DebugContext calls DebugContext on the current Default() logger.
+func DebugContext(ctx context.Context, msg string, args ...any) {
+	output(ctx, slog.LevelDebug, msg, args...)
+}
+
+// Info calls Info on the current Default() logger.
+func Info(msg string, args ...any) { output(context.Background(), slog.LevelInfo, msg, args...) }
+
+// InfoContext calls InfoContext on the current Default() logger.
+func InfoContext(ctx context.Context, msg string, args ...any) {
+	output(ctx, slog.LevelInfo, msg, args...)
+}
+
+// Warn calls Warn on the current Default() logger.
+func Warn(msg string, args ...any) { output(context.Background(), slog.LevelWarn, msg, args...) }
+
+// WarnContext calls WarnContext on the current Default() logger.
+func WarnContext(ctx context.Context, msg string, args ...any) {
+	output(ctx, slog.LevelWarn, msg, args...)
+}
+
+// Error calls Error on the current Default() logger.
+func Error(msg string, args ...any) { output(context.Background(), slog.LevelError, msg, args...) }
+
+// ErrorContext calls ErrorContext on the current Default() logger.
+func ErrorContext(ctx context.Context, msg string, args ...any) {
+	output(ctx, slog.LevelError, msg, args...)
+}
+
+// Log calls Log on the current Default() logger.
+func Log(ctx context.Context, level slog.Level, msg string, args ...any) {
+	output(ctx, level, msg, args...)
+}
+
+// LogAttrs calls LogAttrs on the current Default() logger.
+func LogAttrs(ctx context.Context, level slog.Level, msg string, attrs ...slog.Attr) {
+	l := Default()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if !l.Enabled(ctx, level) {
+		return
+	}
+	var pcs [1]uintptr
+	runtime.Callers(2, pcs[:]) // skip [Callers, LogAttrs]
+	r := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	r.AddAttrs(attrs...)
+	_ = l.Handler().Handle(ctx, r)
+}
+
+// output is the implementation of the output functions, its caller must be
+// called directly by the user code so that the source points at it.
+func output(ctx context.Context, level slog.Level, msg string, args ...any) {
+	l := Default()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if !l.Enabled(ctx, level) {
+		return
+	}
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:]) // skip [Callers, output, Info]
+	r := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	r.Add(args...)
+	_ = l.Handler().Handle(ctx, r)
+}
@@ -0,0 +1,64 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutputFunctions(t *testing.T) {
+	old := Default()
+	defer SetDefault(old)
+
+	var buf bytes.Buffer
+	SetDefault(New(&Config{Mode: ModeCustom, Writer: &buf, Level: slog.LevelDebug, Detail: true}))
+
+	ctx := context.Background()
+	_, _, line, _ := runtime.Caller(0)
+	Debug("debug", "k", 1)
+	DebugContext(ctx, "debug ctx")
+	Info("info")
+	InfoContext(ctx, "info ctx")
+	Warn("warn")
+	WarnContext(ctx, "warn ctx")
+	Error("error")
+	ErrorContext(ctx, "error ctx")
+	Log(ctx, slog.LevelWarn, "log")
+	LogAttrs(ctx, slog.LevelWarn, "log attrs", slog.Int("k", 2))
+	With("app", "demo").Info("with")
+	WithGroup("g").Info("group", "k", 3)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 12)
+	for i, l := range lines {
+		var got struct {
+			Source struct {
+				File string `json:"file"`
+				Line int    `json:"line"`
+			} `json:"source"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(l), &got))
+		assert.True(t, strings.HasSuffix(got.Source.File, "output_test.go"), got.Source.File)
+		assert.Equal(t, line+1+i, got.Source.Line)
+	}
+	assert.Contains(t, lines[0], `"msg":"debug","k":1`)
+	assert.Contains(t, lines[9], `"msg":"log attrs","k":2`)
+	assert.Contains(t, lines[10], `"msg":"with","app":"demo"`)
+	assert.Contains(t, lines[11], `"msg":"group","g":{"k":3}`)
+
+	// SetDefault is honored at call time
+	var other bytes.Buffer
+	SetDefault(New(&Config{Mode: ModeCustom, Writer: &other}))
+	Info("swapped")
+	Debug("hidden")
+	assert.Contains(t, other.String(), `"msg":"swapped"`)
+	assert.NotContains(t, other.String(), "hidden")
+	assert.NotContains(t, buf.String(), "swapped")
+}
@@ -0,0 +1,280 @@
+package logger
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Mask is how the RedactHandler hides a sensitive value.
+type Mask string
+
+const (
+	MaskFull    Mask = "full"    // replace the value with [REDACTED]
+	MaskPartial Mask = "partial" // keep the last 4 characters of values of 8 characters or more, e.g. ************1111
+	MaskHash    Mask = "hash"    // replace the value with a short SHA-256, e.g. sha256:9f86d081884c7d65
+)
+
+// Redacted replaces the values hidden with MaskFull.
+var Redacted = "[REDACTED]"
+
+// DefaultRedactKeys are the key patterns used when RedactConfig.Keys is nil.
+var DefaultRedactKeys = []string{
+	"password", "passwd", "*_password",
+	"secret", "*_secret",
+	"token", "*_token",
+	"api_key", "apikey",
+	"authorization", "cookie", "set-cookie",
+}
+
+// Detectors are the value detectors known by name to RedactConfig.Detectors.
+// When a pattern has a capture group, only the last group is masked.
+var Detectors = map[string]string{
+	"email":  `[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`,
+	"card":   `\b(?:\d[ -]?){12,18}\d\b`,
+	"jwt":    `\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`,
+	"bearer": `(?i)\bbearer\s+([A-Za-z0-9._~+/=-]+)`,
+}
+
+type RedactConfig struct {
+	// Keys are case-insensitive glob patterns of the attr keys whose value is
+	// masked, default DefaultRedactKeys. A pattern containing a dot is matched
+	// against the dotted path of the attr inside its groups, e.g.
+	// "request.header.x-*", the others against the key alone at any depth.
+	Keys      []string `json:"keys" yaml:"keys"`
+	Detectors []string `json:"detectors" yaml:"detectors"` // names in Detectors masked inside string, error, fmt.Stringer and []byte values, default all
+	Patterns  []string `json:"patterns" yaml:"patterns"`   // extra regular expressions masked inside the same values
+	Mask      Mask     `json:"mask" yaml:"mask"`           // full, partial or hash, default full
+}
+
+// RedactHandler masks sensitive attrs, selected by key or detected in string
+// values, before passing records on. Errors, fmt.Stringers and []byte values
+// are checked through their text and become the masked string when something
+// is detected. Groups are walked recursively and LogValuer values are resolved first.
+type RedactHandler struct {
+	next   slog.Handler
+	rules  *redactRules
+	groups []string
+}
+
+type redactRules struct {
+	keys     []string
+	patterns []*regexp.Regexp
+	cards    map[*regexp.Regexp]bool // patterns whose matches must pass the Luhn check
+	mask     Mask
+}
+
+// NewRedactHandler creates a RedactHandler sending the masked records to next.
+func NewRedactHandler(next slog.Handler, conf RedactConfig) (*RedactHandler, error) {
+	rules, err := conf.rules()
+	if err != nil {
+		return nil, err
+	}
+	return &RedactHandler{next: next, rules: rules}, nil
+}
+
+func (c *RedactConfig) rules() (*redactRules, error) {
+	rules := &redactRules{mask: c.Mask, cards: make(map[*regexp.Regexp]bool)}
+	switch rules.mask {
+	case "":
+		rules.mask = MaskFull
+	case MaskFull, MaskPartial, MaskHash:
+	default:
+		return nil, fmt.Errorf("unknown redact mask %q", c.Mask)
+	}
+
+	keys := c.Keys
+	if keys == nil {
+		keys = DefaultRedactKeys
+	}
+	for _, key := range keys {
+		key = strings.ToLower(key)
+		if _, err := path.Match(key, ""); err != nil {
+			return nil, fmt.Errorf("invalid redact key %q: %w", key, err)
+		}
+		rules.keys = append(rules.keys, key)
+	}
+
+	detectors := c.Detectors
+	if detectors == nil {
+		detectors = []string{"email", "card", "jwt", "bearer"}
+	}
+	for _, name := range detectors {
+		expr, ok := Detectors[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown redact detector %q", name)
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redact detector %q: %w", name, err)
+		}
+		rules.patterns = append(rules.patterns, re)
+		rules.cards[re] = name == "card"
+	}
+	for _, expr := range c.Patterns {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redact pattern: %w", err)
+		}
+		rules.patterns = append(rules.patterns, re)
+	}
+	return rules, nil
+}
+
+func (h *RedactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *RedactHandler) Handle(ctx context.Context, r slog.Record) error {
+	nr := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		nr.AddAttrs(h.rules.redact(h.groups, a))
+		return true
+	})
+	return h.next.Handle(ctx, nr)
+}
+
+func (h *RedactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	masked := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		masked[i] = h.rules.redact(h.groups, a)
+	}
+	return &RedactHandler{next: h.next.WithAttrs(masked), rules: h.rules, groups: h.groups}
+}
+
+func (h *RedactHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &RedactHandler{
+		next:   h.next.WithGroup(name),
+		rules:  h.rules,
+		groups: append(h.groups[:len(h.groups):len(h.groups)], name),
+	}
+}
+
+func (r *redactRules) redact(groups []string, a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+	if a.Key != "" && r.sensitive(groups, a.Key) {
+		return slog.Attr{Key: a.Key, Value: r.maskValue(a.Value)}
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindGroup:
+		if a.Key != "" {
+			groups = append(groups[:len(groups):len(groups)], a.Key)
+		}
+		attrs := a.Value.Group()
+		masked := make([]slog.Attr, len(attrs))
+		for i, ga := range attrs {
+			masked[i] = r.redact(groups, ga)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(masked...)}
+	case slog.KindString:
+		return slog.String(a.Key, r.detect(a.Value.String()))
+	case slog.KindAny:
+		// errors and stringers leak tokens and DSNs as much as strings do,
+		// they are kept as is unless their text has something to mask
+		var s string
+		switch v := a.Value.Any().(type) {
+		case error:
+			s = v.Error()
+		case fmt.Stringer:
+			s = v.String()
+		case []byte:
+			s = string(v)
+		default:
+			return a
+		}
+		if masked := r.detect(s); masked != s {
+			return slog.String(a.Key, masked)
+		}
+	}
+	return a
+}
+
+func (r *redactRules) sensitive(groups []string, key string) bool {
+	key = strings.ToLower(key)
+	full := ""
+	for _, pattern := range r.keys {
+		name := key
+		if strings.Contains(pattern, ".") {
+			if full == "" {
+				full = strings.ToLower(strings.Join(append(groups[:len(groups):len(groups)], key), "."))
+			}
+			name = full
+		}
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// detect masks the parts of s matched by the detectors and patterns.
+func (r *redactRules) detect(s string) string {
+	for _, re := range r.patterns {
+		s = re.ReplaceAllStringFunc(s, func(m string) string {
+			if r.cards[re] && !luhn(m) {
+				return m
+			}
+			sub := re.FindStringSubmatchIndex(m)
+			if len(sub) < 4 || sub[len(sub)-2] < 0 {
+				return r.maskString(m)
+			}
+			start, end := sub[len(sub)-2], sub[len(sub)-1]
+			return m[:start] + r.maskString(m[start:end]) + m[end:]
+		})
+	}
+	return s
+}
+
+func (r *redactRules) maskValue(v slog.Value) slog.Value {
+	if r.mask == MaskFull || v.Kind() == slog.KindGroup {
+		return slog.StringValue(Redacted)
+	}
+	return slog.StringValue(r.maskString(v.String()))
+}
+
+func (r *redactRules) maskString(s string) string {
+	switch r.mask {
+	case MaskPartial:
+		runes := []rune(s)
+		keep := 0
+		if len(runes) >= 8 {
+			keep = 4
+		}
+		return strings.Repeat("*", len(runes)-keep) + string(runes[len(runes)-keep:])
+	case MaskHash:
+		sum := sha256.Sum256([]byte(s))
+		return "sha256:" + hex.EncodeToString(sum[:8])
+	default:
+		return Redacted
+	}
+}
+
+// luhn reports whether the digits of s pass the Luhn checksum of card numbers.
+func luhn(s string) bool {
+	sum, n := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && sum%10 == 0
+}
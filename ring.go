@@ -0,0 +1,269 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goapt/logger/sloghttp"
+)
+
+type RingConfig struct {
+	Size     int        `json:"size" yaml:"size"`           // default 1000 records
+	MaxBytes int        `json:"max_bytes" yaml:"max_bytes"` // default 1MB of rendered JSON, the oldest records are evicted first
+	Level    slog.Level `json:"level" yaml:"level"`         // lowest level kept, even below Config.Level, default info
+}
+
+// RingRecord is a record kept by a RingHandler.
+type RingRecord struct {
+	Time    time.Time
+	Level   slog.Level
+	Message string
+	Attrs   []slog.Attr     // resolved attrs of the record and its logger, group keys joined with dots
+	JSON    json.RawMessage // the record rendered as JSON
+}
+
+// Attr returns the value of the attr with the dotted key.
+func (r *RingRecord) Attr(key string) (slog.Value, bool) {
+	for _, a := range r.Attrs {
+		if a.Key == key {
+			return a.Value, true
+		}
+	}
+	return slog.Value{}, false
+}
+
+// RingFilter selects records of a RingHandler, its zero value selects them all.
+type RingFilter struct {
+	Level     slog.Level        // lowest level
+	Message   string            // substring of the message
+	Attrs     map[string]string // dotted keys and the string form of their values
+	RequestID string            // value of the sloghttp.RequestIDKey attr
+	Limit     int               // keep the most recent ones, 0 for all
+}
+
+// RingHandler keeps the most recent records in memory, bounded by count and
+// by bytes. Built from Config.Ring, it sees the records of its level and above
+// whatever Config.Level is, its HTTPHandler lists them.
+type RingHandler struct {
+	json   slog.Handler // renders into state.buf, used under state.mu
+	state  *ringState
+	attrs  []slog.Attr // flattened attrs of the logger
+	prefix string      // dotted prefix of the open groups
+}
+
+type ringState struct {
+	conf    RingConfig
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	records []RingRecord
+	bytes   int
+}
+
+// NewRingHandler creates a RingHandler, opts are used to render the records
+// as JSON, except for its Level replaced by conf.Level.
+func NewRingHandler(conf RingConfig, opts *slog.HandlerOptions) *RingHandler {
+	if conf.Size <= 0 {
+		conf.Size = 1000
+	}
+	if conf.MaxBytes <= 0 {
+		conf.MaxBytes = 1024 * 1024
+	}
+	s := &ringState{conf: conf}
+
+	var o slog.HandlerOptions
+	if opts != nil {
+		o = *opts
+	}
+	o.Level = levelAll
+	return &RingHandler{json: slog.NewJSONHandler(&s.buf, &o), state: s}
+}
+
+func (h *RingHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.state.conf.Level
+}
+
+func (h *RingHandler) Handle(ctx context.Context, r slog.Record) error {
+	rec := RingRecord{
+		Time:    r.Time,
+		Level:   r.Level,
+		Message: r.Message,
+		Attrs:   slices.Clip(h.attrs),
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		rec.Attrs = appendFlatAttr(rec.Attrs, h.prefix, a)
+		return true
+	})
+
+	s := h.state
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf.Reset()
+	if err := h.json.Handle(ctx, r); err != nil {
+		return err
+	}
+	rec.JSON = bytes.Clone(bytes.TrimSuffix(s.buf.Bytes(), []byte("\n")))
+
+	s.records = append(s.records, rec)
+	s.bytes += len(rec.JSON)
+	for len(s.records) > s.conf.Size || (s.bytes > s.conf.MaxBytes && len(s.records) > 1) {
+		s.bytes -= len(s.records[0].JSON)
+		s.records[0] = RingRecord{}
+		s.records = s.records[1:]
+	}
+	return nil
+}
+
+func (h *RingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	flat := slices.Clip(h.attrs)
+	for _, a := range attrs {
+		flat = appendFlatAttr(flat, h.prefix, a)
+	}
+	return &RingHandler{json: h.json.WithAttrs(attrs), state: h.state, attrs: flat, prefix: h.prefix}
+}
+
+func (h *RingHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &RingHandler{json: h.json.WithGroup(name), state: h.state, attrs: h.attrs, prefix: h.prefix + name + "."}
+}
+
+// Records returns the kept records selected by f, oldest first.
+func (h *RingHandler) Records(f RingFilter) []RingRecord {
+	h.state.mu.Lock()
+	defer h.state.mu.Unlock()
+
+	var records []RingRecord
+	for _, rec := range h.state.records {
+		if f.match(&rec) {
+			records = append(records, rec)
+		}
+	}
+	if f.Limit > 0 && len(records) > f.Limit {
+		records = records[len(records)-f.Limit:]
+	}
+	return records
+}
+
+func (f *RingFilter) match(rec *RingRecord) bool {
+	if rec.Level < f.Level || !strings.Contains(rec.Message, f.Message) {
+		return false
+	}
+	if f.RequestID != "" {
+		if v, ok := rec.Attr(sloghttp.RequestIDKey); !ok || v.String() != f.RequestID {
+			return false
+		}
+	}
+	for key, want := range f.Attrs {
+		if v, ok := rec.Attr(key); !ok || v.String() != want {
+			return false
+		}
+	}
+	return true
+}
+
+// HTTPHandler returns an http.Handler listing the kept records, oldest first.
+//
+//	GET  ?level=warn           lowest level
+//	     &msg=timeout          substring of the message
+//	     &attr=user.id=42      attr equality, repeatable
+//	     &request_id=abc       sloghttp request ID
+//	     &limit=100            most recent records only
+//	     &format=text          logfmt lines instead of a JSON array
+func (h *RingHandler) HTTPHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", "GET")
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+			return
+		}
+
+		q := r.URL.Query()
+		f := RingFilter{Level: levelAll, Message: q.Get("msg"), RequestID: q.Get("request_id")}
+		if v := q.Get("level"); v != "" {
+			if err := f.Level.UnmarshalText([]byte(v)); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+		}
+		if v := q.Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid limit " + strconv.Quote(v)})
+				return
+			}
+			f.Limit = n
+		}
+		for _, attr := range q["attr"] {
+			key, value, ok := strings.Cut(attr, "=")
+			if !ok {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid attr " + strconv.Quote(attr) + ", want key=value"})
+				return
+			}
+			if f.Attrs == nil {
+				f.Attrs = make(map[string]string)
+			}
+			f.Attrs[key] = value
+		}
+
+		records := h.Records(f)
+		if q.Get("format") == "text" {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			var buf []byte
+			for _, rec := range records {
+				buf = rec.appendText(buf[:0])
+				_, _ = w.Write(buf)
+			}
+			return
+		}
+
+		raw := make([]json.RawMessage, len(records))
+		for i, rec := range records {
+			raw[i] = rec.JSON
+		}
+		writeJSON(w, http.StatusOK, raw)
+	})
+}
+
+// appendText appends the record as a logfmt line.
+func (r *RingRecord) appendText(buf []byte) []byte {
+	buf = append(buf, "time="...)
+	buf = r.Time.AppendFormat(buf, time.RFC3339Nano)
+	buf = append(buf, " level="...)
+	buf = append(buf, r.Level.String()...)
+	buf = append(buf, " msg="...)
+	buf = appendLogfmtString(buf, r.Message)
+	for _, a := range r.Attrs {
+		buf = append(buf, ' ')
+		buf = appendLogfmtKey(buf, a.Key)
+		buf = append(buf, '=')
+		buf = appendLogfmtValue(buf, a.Value)
+	}
+	return append(buf, '\n')
+}
+
+// appendFlatAttr appends a, resolved, to attrs, groups are flattened into dotted keys.
+func appendFlatAttr(attrs []slog.Attr, prefix string, a slog.Attr) []slog.Attr {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return attrs
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			attrs = appendFlatAttr(attrs, prefix, ga)
+		}
+		return attrs
+	}
+	return append(attrs, slog.Attr{Key: prefix + a.Key, Value: a.Value})
+}
@@ -0,0 +1,106 @@
+package logger
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/goapt/logger/sloghttp"
+)
+
+func TestRingHandler_Bounds(t *testing.T) {
+	h := NewRingHandler(RingConfig{Size: 3}, nil)
+	l := slog.New(h)
+	for _, msg := range []string{"a", "b", "c", "d"} {
+		l.Info(msg)
+	}
+	var got []string
+	for _, rec := range h.Records(RingFilter{}) {
+		got = append(got, rec.Message)
+	}
+	assert.Equal(t, []string{"b", "c", "d"}, got)
+
+	h = NewRingHandler(RingConfig{MaxBytes: 200}, nil)
+	l = slog.New(h)
+	l.Info("one", "pad", strings.Repeat("x", 80))
+	l.Info("two", "pad", strings.Repeat("x", 80))
+	l.Info("three", "pad", strings.Repeat("x", 80))
+	records := h.Records(RingFilter{})
+	require.Len(t, records, 1)
+	assert.Equal(t, "three", records[0].Message)
+}
+
+func TestRingHandler_Filter(t *testing.T) {
+	h := NewRingHandler(RingConfig{Level: slog.LevelDebug}, nil)
+	l := slog.New(h).With("app", "demo")
+	l.Debug("cache miss", "key", "k1")
+	l.WithGroup("user").Info("login", "id", 42, sloghttp.RequestIDKey, "nested")
+	l.Warn("slow query", sloghttp.RequestIDKey, "r1", slog.Group("db", "table", "orders"))
+	l.Error("query timeout", sloghttp.RequestIDKey, "r1")
+
+	messages := func(f RingFilter) []string {
+		var got []string
+		for _, rec := range h.Records(f) {
+			got = append(got, rec.Message)
+		}
+		return got
+	}
+	assert.Equal(t, []string{"cache miss", "login", "slow query", "query timeout"}, messages(RingFilter{Level: slog.LevelDebug}))
+	assert.Equal(t, []string{"slow query", "query timeout"}, messages(RingFilter{Level: slog.LevelWarn}))
+	assert.Equal(t, []string{"slow query", "query timeout"}, messages(RingFilter{Level: slog.LevelDebug, Message: "query"}))
+	assert.Equal(t, []string{"login"}, messages(RingFilter{Level: slog.LevelDebug, Attrs: map[string]string{"user.id": "42", "app": "demo"}}))
+	assert.Equal(t, []string{"slow query"}, messages(RingFilter{Level: slog.LevelDebug, Attrs: map[string]string{"db.table": "orders"}}))
+	assert.Equal(t, []string{"slow query", "query timeout"}, messages(RingFilter{Level: slog.LevelDebug, RequestID: "r1"}))
+	assert.Equal(t, []string{"query timeout"}, messages(RingFilter{Level: slog.LevelDebug, RequestID: "r1", Limit: 1}))
+}
+
+func TestLogger_Ring(t *testing.T) {
+	var buf syncBuffer
+	l, err := Open(&Config{Mode: ModeCustom, Writer: &buf, Level: slog.LevelWarn, Ring: &RingConfig{Level: slog.LevelDebug}, Redact: &RedactConfig{}})
+	require.NoError(t, err)
+	defer l.Close()
+
+	l.Debug("debug", "password", "hunter2")
+	l.Warn("warn", "n", 1)
+	assert.NotContains(t, buf.String(), `"msg":"debug"`, "the main sink keeps its level")
+	assert.Contains(t, buf.String(), `"msg":"warn"`)
+
+	srv := httptest.NewServer(l.Ring().HTTPHandler())
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + "?level=debug")
+	require.NoError(t, err)
+	var records []map[string]any
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&records))
+	res.Body.Close()
+	require.Len(t, records, 2)
+	assert.Equal(t, "debug", records[0]["msg"])
+	assert.Equal(t, "[REDACTED]", records[0]["password"])
+
+	res, err = http.Get(srv.URL + "?format=text&attr=n=1")
+	require.NoError(t, err)
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	assert.Regexp(t, `^time=\S+ level=WARN msg=warn n=1\n$`, string(body))
+
+	res, err = http.Get(srv.URL + "?attr=n")
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	// named loggers share the ring
+	old := Default()
+	defer SetDefault(old)
+	SetDefault(l.Logger)
+	Named("billing").Debug("named")
+	named := l.Ring().Records(RingFilter{Level: slog.LevelDebug, Attrs: map[string]string{NameKey: "billing"}})
+	require.Len(t, named, 1)
+	assert.Equal(t, "named", named[0].Message)
+}
@@ -85,7 +85,9 @@ type Roller struct {
 	mu   sync.Mutex
 
 	millCh    chan bool
+	millDone  chan struct{}
 	startMill sync.Once
+	closed    bool
 }
 
 type Option func(roller *Roller)
@@ -143,6 +145,9 @@ func (r *Roller) Write(p []byte) (n int, err error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	if r.closed {
+		return 0, os.ErrClosed
+	}
 	if r.size+writeLen > r.maxSize {
 		if err := r.rotate(); err != nil {
 			return 0, err
@@ -155,11 +160,33 @@ func (r *Roller) Write(p []byte) (n int, err error) {
 	return n, err
 }
 
-// Close implements io.Closer, and closes the current logfile.
+// Close implements io.Closer, and closes the current logfile.  It also stops
+// the goroutine removing old log files, waiting for a pending run to finish.
+// Writes and rotations after Close fail with os.ErrClosed.
 func (r *Roller) Close() error {
+	r.mu.Lock()
+	err := r.close()
+	millDone := r.millDone
+	if !r.closed && r.millCh != nil {
+		close(r.millCh)
+	}
+	r.closed = true
+	r.mu.Unlock()
+
+	if millDone != nil {
+		<-millDone
+	}
+	return err
+}
+
+// Sync commits the current contents of the logfile to stable storage.
+func (r *Roller) Sync() error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	return r.close()
+	if r.file == nil {
+		return nil
+	}
+	return r.file.Sync()
 }
 
 // close closes the file if it is open.
@@ -180,6 +207,9 @@ func (r *Roller) close() error {
 func (r *Roller) Rotate() error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	if r.closed {
+		return os.ErrClosed
+	}
 	return r.rotate()
 }
 
@@ -313,6 +343,7 @@ func (r *Roller) millRunOnce() error {
 // millRun runs in a goroutine to manage post-rotation compression and removal
 // of old log files.
 func (r *Roller) millRun() {
+	defer close(r.millDone)
 	for range r.millCh {
 		// what am I going to do, log this?
 		_ = r.millRunOnce()
@@ -322,8 +353,12 @@ func (r *Roller) millRun() {
 // mill performs post-rotation compression and removal of stale log files,
 // starting the mill goroutine if necessary.
 func (r *Roller) mill() {
+	if r.closed {
+		return
+	}
 	r.startMill.Do(func() {
 		r.millCh = make(chan bool, 1)
+		r.millDone = make(chan struct{})
 		go r.millRun()
 	})
 	select {
@@ -510,6 +510,35 @@ func TestRotate(t *testing.T) {
 	existsWithContent(filename, b2, t)
 }
 
+func TestSyncAndClose(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestSyncAndClose", t)
+	defer os.RemoveAll(dir)
+
+	l, err := NewRoller(logFile(dir), 100)
+	assert.NoError(t, err)
+	b := []byte("boo!")
+	_, err = l.Write(b)
+	assert.NoError(t, err)
+	assert.NoError(t, l.Sync())
+	existsWithContent(logFile(dir), b, t)
+
+	assert.NoError(t, l.Close())
+	// the mill goroutine has exited
+	select {
+	case <-l.millDone:
+	default:
+		t.Fatal("mill goroutine still running after Close")
+	}
+
+	_, err = l.Write(b)
+	assert.ErrorIs(t, err, os.ErrClosed)
+	assert.ErrorIs(t, l.Rotate(), os.ErrClosed)
+	assert.NoError(t, l.Sync())
+	assert.NoError(t, l.Close())
+	fileCount(dir, 1, t)
+}
+
 // makeTempDir creates a file with a semi-unique name in the OS temp directory.
 // It should be based on the name of the test, to keep parallel tests from
 // colliding, and must be cleaned up after the test is finished.
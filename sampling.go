@@ -0,0 +1,172 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// SamplingRule keeps the First records of a message in each interval, then
+// every Thereafter-th record. Thereafter 0 drops the rest, 1 keeps them all.
+// The zero rule keeps every record, as when sampling is off.
+type SamplingRule struct {
+	First      int `json:"first" yaml:"first"`
+	Thereafter int `json:"thereafter" yaml:"thereafter"`
+}
+
+type SamplingConfig struct {
+	Interval     time.Duration `json:"interval" yaml:"interval"` // default 1s
+	SamplingRule `yaml:",inline"`
+	// Levels overrides the rule per level. Records at ERROR and above are
+	// never sampled unless their level is listed here.
+	Levels map[slog.Level]SamplingRule `json:"levels" yaml:"levels"`
+}
+
+// SamplingHandler drops repeated records, counted per level and message over
+// an interval. When an interval ends, a summary record such as
+// "suppressed 4,812 similar messages" is logged for every sampled message.
+type SamplingHandler struct {
+	next  slog.Handler
+	state *samplingState
+}
+
+type samplingState struct {
+	conf     SamplingConfig
+	mu       sync.Mutex
+	counters map[samplingKey]*samplingCounter
+	stop     chan struct{}
+	done     chan struct{}
+	once     sync.Once
+}
+
+type samplingKey struct {
+	level slog.Level
+	msg   string
+}
+
+type samplingCounter struct {
+	n          int          // records in the current interval
+	suppressed int          // records dropped in the current interval
+	h          slog.Handler // handler of the last dropped record, logs the summary
+}
+
+// NewSamplingHandler creates a SamplingHandler sending the sampled records to next.
+// Close it to stop the goroutine ending the intervals.
+func NewSamplingHandler(next slog.Handler, conf SamplingConfig) *SamplingHandler {
+	if conf.Interval <= 0 {
+		conf.Interval = time.Second
+	}
+	s := &samplingState{
+		conf:     conf,
+		counters: make(map[samplingKey]*samplingCounter),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go s.run()
+	return &SamplingHandler{next: next, state: s}
+}
+
+func (h *SamplingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *SamplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	if h.state.sample(h.next, r) {
+		return h.next.Handle(ctx, r)
+	}
+	return nil
+}
+
+func (h *SamplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &SamplingHandler{next: h.next.WithAttrs(attrs), state: h.state}
+}
+
+func (h *SamplingHandler) WithGroup(name string) slog.Handler {
+	return &SamplingHandler{next: h.next.WithGroup(name), state: h.state}
+}
+
+// Close ends the current interval, logging its summaries, and stops the background goroutine.
+func (h *SamplingHandler) Close() error {
+	h.state.once.Do(func() { close(h.state.stop) })
+	<-h.state.done
+	return nil
+}
+
+// rule returns the rule of level, false when its records are not sampled.
+func (s *samplingState) rule(level slog.Level) (SamplingRule, bool) {
+	rule, ok := s.conf.Levels[level]
+	if !ok {
+		rule, ok = s.conf.SamplingRule, level < slog.LevelError
+	}
+	return rule, ok && rule != SamplingRule{}
+}
+
+// sample reports whether r is kept.
+func (s *samplingState) sample(h slog.Handler, r slog.Record) bool {
+	rule, ok := s.rule(r.Level)
+	if !ok {
+		return true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := samplingKey{level: r.Level, msg: r.Message}
+	c := s.counters[key]
+	if c == nil {
+		c = &samplingCounter{}
+		s.counters[key] = c
+	}
+	c.n++
+	if c.n <= rule.First || (rule.Thereafter > 0 && (c.n-rule.First)%rule.Thereafter == 0) {
+		return true
+	}
+	c.suppressed++
+	c.h = h
+	return false
+}
+
+func (s *samplingState) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.conf.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.endInterval()
+		case <-s.stop:
+			s.endInterval()
+			return
+		}
+	}
+}
+
+// endInterval resets the counters and logs a summary for every message dropped in the interval.
+func (s *samplingState) endInterval() {
+	s.mu.Lock()
+	counters := s.counters
+	s.counters = make(map[samplingKey]*samplingCounter, len(counters))
+	s.mu.Unlock()
+
+	for key, c := range counters {
+		if c.suppressed == 0 {
+			continue
+		}
+		r := slog.NewRecord(time.Now(), key.level, "suppressed "+formatCount(c.suppressed)+" similar messages", 0)
+		r.AddAttrs(slog.String("sampled_msg", key.msg), slog.Int("suppressed", c.suppressed))
+		_ = c.h.Handle(context.Background(), r)
+	}
+}
+
+// formatCount formats n with thousands separators, e.g. 4,812.
+func formatCount(n int) string {
+	s := strconv.Itoa(n)
+	if n < 0 {
+		return "-" + formatCount(-n)
+	}
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
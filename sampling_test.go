@@ -0,0 +1,137 @@
+package logger
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSamplingHandler(t *testing.T) {
+	var buf syncBuffer
+	h := NewSamplingHandler(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		Level: levelAll,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}), SamplingConfig{
+		Interval:     time.Hour,
+		SamplingRule: SamplingRule{First: 2, Thereafter: 3},
+		Levels:       map[slog.Level]SamplingRule{slog.LevelWarn: {First: 1}},
+	})
+	l := slog.New(h).With("app", "demo")
+
+	for i := range 10 {
+		l.Info("hot", "i", i)
+		l.Warn("slow")
+		l.Error("failed")
+	}
+	l.Debug("other")
+
+	out := buf.String()
+	assert.Equal(t, 4, strings.Count(out, "msg=hot"))
+	for _, i := range []string{"i=0", "i=1", "i=4", "i=7"} {
+		assert.Contains(t, out, "msg=hot app=demo "+i+"\n")
+	}
+	assert.Equal(t, 1, strings.Count(out, "msg=slow"))
+	assert.Equal(t, 10, strings.Count(out, "msg=failed"), "errors are never sampled")
+	assert.Equal(t, 1, strings.Count(out, "msg=other"))
+	assert.NotContains(t, out, "suppressed")
+
+	require.NoError(t, h.Close())
+	out = buf.String()
+	assert.Contains(t, out, `level=INFO msg="suppressed 6 similar messages" app=demo sampled_msg=hot suppressed=6`)
+	assert.Contains(t, out, `level=WARN msg="suppressed 9 similar messages" app=demo sampled_msg=slow suppressed=9`)
+}
+
+func TestSamplingHandler_ZeroRule(t *testing.T) {
+	for name, conf := range map[string]SamplingConfig{
+		"empty":    {},
+		"interval": {Interval: time.Hour},
+		"levels":   {Levels: map[slog.Level]SamplingRule{slog.LevelInfo: {}, slog.LevelWarn: {First: 1}}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var buf syncBuffer
+			h := NewSamplingHandler(slog.NewTextHandler(&buf, nil), conf)
+			l := slog.New(h)
+			for range 3 {
+				l.Info("hot")
+			}
+			require.NoError(t, h.Close())
+			assert.Equal(t, 3, strings.Count(buf.String(), "msg=hot"))
+			assert.NotContains(t, buf.String(), "suppressed")
+		})
+	}
+}
+
+func TestSamplingHandler_Interval(t *testing.T) {
+	var buf syncBuffer
+	h := NewSamplingHandler(slog.NewJSONHandler(&buf, nil), SamplingConfig{
+		Interval:     20 * time.Millisecond,
+		SamplingRule: SamplingRule{First: 1},
+	})
+	defer h.Close()
+	l := slog.New(h)
+
+	l.Info("hot")
+	l.Info("hot")
+	assert.Eventually(t, func() bool {
+		return strings.Contains(buf.String(), `"msg":"suppressed 1 similar messages"`)
+	}, time.Second, time.Millisecond)
+
+	// a new interval starts counting again
+	l.Info("hot")
+	assert.Equal(t, 2, strings.Count(buf.String(), `"msg":"hot"`))
+}
+
+func TestLogger_Sampling(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+sampling:
+  interval: 1h
+  first: 1
+  levels:
+    info:
+      first: 0
+      thereafter: 1
+`), 0644))
+	conf, err := LoadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, &SamplingConfig{
+		Interval:     time.Hour,
+		SamplingRule: SamplingRule{First: 1},
+		Levels:       map[slog.Level]SamplingRule{slog.LevelInfo: {Thereafter: 1}},
+	}, conf.Sampling)
+
+	var buf syncBuffer
+	conf.Mode, conf.Writer = ModeCustom, &buf
+	l, err := Open(conf)
+	require.NoError(t, err)
+	for range 3 {
+		l.Info("info")
+		l.Warn("warn")
+	}
+	require.NoError(t, l.Close())
+
+	out := buf.String()
+	assert.Equal(t, 3, strings.Count(out, `"msg":"info"`))
+	assert.Equal(t, 1, strings.Count(out, `"msg":"warn"`))
+	assert.Contains(t, out, `"msg":"suppressed 2 similar messages","sampled_msg":"warn"`)
+
+	err = (&Config{Sampling: &SamplingConfig{SamplingRule: SamplingRule{First: -1}}}).Validate()
+	assert.ErrorContains(t, err, "sampling first and thereafter cannot be negative")
+}
+
+func TestFormatCount(t *testing.T) {
+	for n, want := range map[int]string{0: "0", 999: "999", 4812: "4,812", 1234567: "1,234,567", -1000: "-1,000"} {
+		assert.Equal(t, want, formatCount(n))
+	}
+}
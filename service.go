@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"log/slog"
+	"maps"
+	"os"
+	"runtime/debug"
+	"slices"
+)
+
+type ServiceConfig struct {
+	Name        string `json:"name" yaml:"name"`
+	Version     string `json:"version" yaml:"version"`         // default the main module version of the binary, if any
+	Environment string `json:"environment" yaml:"environment"` // e.g. production
+	Host        string `json:"host" yaml:"host"`               // default os.Hostname()
+	BuildInfo   bool   `json:"build_info" yaml:"build_info"`   // add the Go version and VCS revision of the binary
+	Group       string `json:"group" yaml:"group"`             // put the attrs under this group, default top level
+}
+
+// attrs returns service, version, environment, host and pid, omitting the
+// unknown ones, and build when BuildInfo is set.
+func (c *ServiceConfig) attrs() []slog.Attr {
+	info, _ := debug.ReadBuildInfo()
+
+	var attrs []slog.Attr
+	if c.Name != "" {
+		attrs = append(attrs, slog.String("service", c.Name))
+	}
+	version := c.Version
+	if version == "" && info != nil && info.Main.Version != "(devel)" {
+		version = info.Main.Version
+	}
+	if version != "" {
+		attrs = append(attrs, slog.String("version", version))
+	}
+	if c.Environment != "" {
+		attrs = append(attrs, slog.String("environment", c.Environment))
+	}
+	host := c.Host
+	if host == "" {
+		host, _ = os.Hostname()
+	}
+	if host != "" {
+		attrs = append(attrs, slog.String("host", host))
+	}
+	attrs = append(attrs, slog.Int("pid", os.Getpid()))
+
+	if c.BuildInfo && info != nil {
+		build := []slog.Attr{slog.String("go", info.GoVersion)}
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				build = append(build, slog.String("revision", s.Value))
+			case "vcs.time":
+				build = append(build, slog.String("time", s.Value))
+			case "vcs.modified":
+				build = append(build, slog.Bool("modified", s.Value == "true"))
+			}
+		}
+		attrs = append(attrs, slog.Attr{Key: "build", Value: slog.GroupValue(build...)})
+	}
+
+	if c.Group != "" {
+		return []slog.Attr{{Key: c.Group, Value: slog.GroupValue(attrs...)}}
+	}
+	return attrs
+}
+
+// staticAttrs returns the attrs of c.Service and c.Fields, added to every record.
+func (c *Config) staticAttrs() []slog.Attr {
+	var attrs []slog.Attr
+	if c.Service != nil {
+		attrs = c.Service.attrs()
+	}
+	for _, key := range slices.Sorted(maps.Keys(c.Fields)) {
+		attrs = append(attrs, slog.Any(key, c.Fields[key]))
+	}
+	return attrs
+}
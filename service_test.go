@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Service(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&Config{
+		Mode:    ModeCustom,
+		Writer:  &buf,
+		Service: &ServiceConfig{Name: "billing", Version: "v1.2.3", Environment: "production", Host: "web-1"},
+		Fields:  map[string]any{"region": "eu-west-1", "cell": 3},
+	})
+	l.Info("hello")
+	assert.Contains(t, buf.String(), `"msg":"hello","service":"billing","version":"v1.2.3","environment":"production","host":"web-1","pid":`)
+	assert.Contains(t, buf.String(), `,"cell":3,"region":"eu-west-1"}`)
+}
+
+func TestConfig_ServiceGroup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+service:
+  name: billing
+  group: service
+  build_info: true
+fields:
+  team: payments
+`), 0644))
+	conf, err := LoadConfig(path)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	conf.Mode, conf.Writer = ModeCustom, &buf
+	New(conf).Info("hello")
+
+	var got struct {
+		Service struct {
+			Service string         `json:"service"`
+			Host    string         `json:"host"`
+			PID     int            `json:"pid"`
+			Build   map[string]any `json:"build"`
+		} `json:"service"`
+		Team string `json:"team"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	assert.Equal(t, "billing", got.Service.Service)
+	assert.NotEmpty(t, got.Service.Host)
+	assert.Equal(t, os.Getpid(), got.Service.PID)
+	assert.Contains(t, got.Service.Build, "go")
+	assert.Equal(t, "payments", got.Team)
+}
@@ -0,0 +1,195 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+)
+
+// SinkConfig is an output of a logger, see Config.Sinks.
+type SinkConfig struct {
+	Mode     Mode           `json:"mode" yaml:"mode"`           // default std
+	Level    slog.Level     `json:"level" yaml:"level"`         // default info
+	Format   Format         `json:"format" yaml:"format"`       // default json (console when std or stderr is a terminal)
+	FileName string         `json:"filename" yaml:"filename"`   // only used for file mode
+	MaxFiles int            `json:"max_files" yaml:"max_files"` // default keep the last 3 files
+	MaxSize  int64          `json:"max_size" yaml:"max_size"`   // default 200MB
+	Writer   io.Writer      `json:"-" yaml:"-"`                 // only used for custom mode
+	Network  *NetworkConfig `json:"network" yaml:"network"`     // only used for network mode
+	Syslog   *SyslogConfig  `json:"syslog" yaml:"syslog"`       // only used for syslog mode, which ignores Format and Async
+	Journal  *JournalConfig `json:"journal" yaml:"journal"`     // only used for journal mode, which ignores Format and Async
+	GELF     *GELFConfig    `json:"gelf" yaml:"gelf"`           // only used for gelf format
+	OTLP     *OTLPConfig    `json:"otlp" yaml:"otlp"`           // only used for otlp mode, which ignores Format and Async
+	Async    *AsyncConfig   `json:"async" yaml:"async"`         // write through a bounded queue, default synchronous
+}
+
+// sink returns the sink described by the output settings of c.
+func (c *Config) sink() SinkConfig {
+	return SinkConfig{
+		Mode:     c.Mode,
+		Level:    levelAll,
+		Format:   c.Format,
+		FileName: c.FileName,
+		MaxFiles: c.MaxFiles,
+		MaxSize:  c.MaxSize,
+		Writer:   c.Writer,
+		Network:  c.Network,
+		Syslog:   c.Syslog,
+		Journal:  c.Journal,
+		GELF:     c.GELF,
+		OTLP:     c.OTLP,
+		Async:    c.Async,
+	}
+}
+
+func newSinkHandler(sink *SinkConfig, opts *slog.HandlerOptions, l *Logger) (slog.Handler, error) {
+	if sink.Mode == ModeSyslog {
+		var conf SyslogConfig
+		if sink.Syslog != nil {
+			conf = *sink.Syslog
+		}
+		sinkOpts := *opts
+		sinkOpts.Level = sink.Level
+		h, err := NewSyslogHandler(conf, &sinkOpts)
+		if err != nil {
+			return nil, fmt.Errorf("new syslog writer error: %w", err)
+		}
+		l.track(h)
+		return h, nil
+	}
+	if sink.Mode == ModeJournal {
+		var conf JournalConfig
+		if sink.Journal != nil {
+			conf = *sink.Journal
+		}
+		sinkOpts := *opts
+		sinkOpts.Level = sink.Level
+		h, err := NewJournalHandler(conf, &sinkOpts)
+		if err != nil {
+			return nil, fmt.Errorf("new journal writer error: %w", err)
+		}
+		l.track(h)
+		return h, nil
+	}
+	if sink.Mode == ModeOTLP {
+		var conf OTLPConfig
+		if sink.OTLP != nil {
+			conf = *sink.OTLP
+		}
+		sinkOpts := *opts
+		sinkOpts.Level = sink.Level
+		h, err := NewOTLPHandler(conf, &sinkOpts)
+		if err != nil {
+			return nil, fmt.Errorf("new otlp exporter error: %w", err)
+		}
+		l.track(h)
+		return h, nil
+	}
+
+	var gelf GELFConfig
+	if sink.GELF != nil {
+		gelf = *sink.GELF
+	}
+
+	var w io.Writer
+	switch sink.Mode {
+	case ModeFile:
+		roller, err := newRoller(sink)
+		if err != nil {
+			return nil, err
+		}
+		l.track(roller)
+		w = roller
+	case ModeCustom:
+		w = sink.Writer
+	case ModeNetwork:
+		if sink.Network == nil {
+			return nil, errors.New("network mode requires a network address")
+		}
+		var framing netFraming
+		if sink.Format == FormatGELF {
+			framing = gelf.framing(sink.Network.Network)
+		}
+		nw, err := newNetWriter(*sink.Network, framing)
+		if err != nil {
+			return nil, fmt.Errorf("new network writer error: %w", err)
+		}
+		l.track(nw)
+		w = nw
+	case ModeStderr:
+		w = os.Stderr
+	default:
+		w = os.Stdout
+	}
+
+	format := sink.Format
+	if format == "" && sink.Mode != ModeFile && sink.Mode != ModeCustom && isTerminal(w) {
+		format = FormatConsole
+	}
+
+	sinkOpts := *opts
+	sinkOpts.Level = sink.Level
+	build := func(w io.Writer) slog.Handler {
+		if format == FormatGELF {
+			return NewGELFHandler(w, gelf, &sinkOpts)
+		}
+		return newFormatHandler(w, format, &sinkOpts)
+	}
+
+	if sink.Async == nil {
+		return build(w), nil
+	}
+	async := *sink.Async
+	if sink.Mode == ModeFile && (async.BatchSize <= 0 || int64(async.BatchSize) > sink.MaxSize) {
+		// the roller rejects writes longer than a file
+		async.BatchSize = int(min(sink.MaxSize, 64*1024))
+	}
+	h := NewAsyncHandler(w, async, build)
+	l.track(h)
+	return h, nil
+}
+
+// fanoutHandler sends each record to every handler enabled for its level.
+type fanoutHandler struct {
+	handlers []slog.Handler
+}
+
+func (h *fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, sh := range h.handlers {
+		if sh.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *fanoutHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, sh := range h.handlers {
+		if sh.Enabled(ctx, r.Level) {
+			if err := sh.Handle(ctx, r.Clone()); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (h *fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, sh := range h.handlers {
+		handlers[i] = sh.WithAttrs(attrs)
+	}
+	return &fanoutHandler{handlers: handlers}
+}
+
+func (h *fanoutHandler) WithGroup(name string) slog.Handler {
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, sh := range h.handlers {
+		handlers[i] = sh.WithGroup(name)
+	}
+	return &fanoutHandler{handlers: handlers}
+}
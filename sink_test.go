@@ -0,0 +1,82 @@
+package logger
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogger_Sinks(t *testing.T) {
+	var info, warn, alert bytes.Buffer
+	l := New(&Config{
+		Level: slog.LevelDebug,
+		Sinks: []SinkConfig{
+			{Mode: ModeCustom, Writer: &info, Level: slog.LevelInfo},
+			{Mode: ModeCustom, Writer: &warn, Level: slog.LevelWarn, Format: FormatText},
+			{Mode: ModeCustom, Writer: &alert, Level: slog.LevelError, Format: FormatLogfmt},
+		},
+	})
+
+	l = l.With("app", "demo")
+	l.Debug("debug")
+	l.Info("info")
+	l.Warn("warn")
+	l.Error("error")
+
+	assert.Equal(t, 3, strings.Count(info.String(), "\n"))
+	assert.NotContains(t, info.String(), `"msg":"debug"`)
+	assert.Contains(t, info.String(), `"msg":"info","app":"demo"`)
+
+	assert.Equal(t, 2, strings.Count(warn.String(), "\n"))
+	assert.Contains(t, warn.String(), "level=WARN msg=warn app=demo")
+
+	assert.Equal(t, 1, strings.Count(alert.String(), "\n"))
+	assert.Contains(t, alert.String(), "level=ERROR msg=error app=demo")
+}
+
+func TestLogger_SinksFromFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "log.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+level: info
+sinks:
+  - mode: file
+    filename: `+filepath.Join(dir, "app.log")+`
+  - mode: file
+    level: error
+    filename: `+filepath.Join(dir, "alert.log")+`
+    async:
+      queue_size: 16
+`), 0644))
+
+	conf, err := LoadConfig(path)
+	require.NoError(t, err)
+	l, err := Open(conf)
+	require.NoError(t, err)
+
+	l.Info("info")
+	l.Error("error")
+	require.NoError(t, l.Close())
+
+	app, err := os.ReadFile(filepath.Join(dir, "app.log"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(string(app), "\n"))
+
+	alert, err := os.ReadFile(filepath.Join(dir, "alert.log"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(alert), "\n"))
+	assert.Contains(t, string(alert), `"msg":"error"`)
+}
+
+func TestConfig_ValidateSinks(t *testing.T) {
+	err := (&Config{Sinks: []SinkConfig{{Mode: ModeStderr}, {Mode: "kafka", MaxSize: -1}}}).Validate()
+	assert.ErrorContains(t, err, `sinks[1]: unknown mode "kafka"`)
+	assert.ErrorContains(t, err, "sinks[1]: max_size cannot be negative")
+	assert.NotContains(t, err.Error(), "sinks[0]")
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"log/slog"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -57,6 +58,11 @@ type Config struct {
 	WithTraceID        bool
 
 	Filters []Filter
+
+	// Buffer, when set, scopes a buffer of low-level records to each request,
+	// such as logger.BufferContext: the middleware ends it once the handler
+	// returns, flushing it when the status maps to ServerErrorLevel.
+	Buffer func(ctx context.Context) (context.Context, func(flush bool))
 }
 
 var DefaultConfig = Config{
@@ -76,6 +82,18 @@ var DefaultConfig = Config{
 	Filters: []Filter{},
 }
 
+// statusLevel returns the level of the log of a response with status.
+func (c Config) statusLevel(status int) slog.Level {
+	switch {
+	case status >= http.StatusInternalServerError:
+		return c.ServerErrorLevel
+	case status >= http.StatusBadRequest:
+		return c.ClientErrorLevel
+	default:
+		return c.DefaultLevel
+	}
+}
+
 func log(logger *slog.Logger, config Config, r *http.Request, wr WrapResponse, br *bodyReader, start time.Time, err error) {
 	for _, filter := range config.Filters {
 		if !filter(wr, r) {
@@ -180,23 +198,9 @@ func log(logger *slog.Logger, config Config, r *http.Request, wr WrapResponse, b
 		baseAttributes...,
 	)
 
-	if v := r.Context().Value(customAttributesCtxKey); v != nil {
-		if m, ok := v.(*sync.Map); ok {
-			m.Range(func(key, value any) bool {
-				attributes = append(attributes, slog.Attr{Key: key.(string), Value: value.(slog.Value)})
-				return true
-			})
-		}
-	}
+	attributes = append(attributes, GetContextAttributes(r.Context())...)
 
-	level := config.DefaultLevel
-	if status >= http.StatusInternalServerError {
-		level = config.ServerErrorLevel
-	} else if status >= http.StatusBadRequest {
-		level = config.ClientErrorLevel
-	}
-
-	logger.LogAttrs(r.Context(), level, strconv.Itoa(status)+": "+http.StatusText(status), attributes...)
+	logger.LogAttrs(r.Context(), config.statusLevel(status), strconv.Itoa(status)+": "+http.StatusText(status), attributes...)
 }
 
 // GetRequestID returns the request identifier.
@@ -237,6 +241,23 @@ func AddContextAttributes(ctx context.Context, attrs ...slog.Attr) {
 	}
 }
 
+// GetContextAttributes returns the custom attributes of the context sorted by key,
+// see NewContextAttributes.
+func GetContextAttributes(ctx context.Context) []slog.Attr {
+	m, ok := ctx.Value(customAttributesCtxKey).(*sync.Map)
+	if !ok {
+		return nil
+	}
+
+	var attrs []slog.Attr
+	m.Range(func(key, value any) bool {
+		attrs = append(attrs, slog.Attr{Key: key.(string), Value: value.(slog.Value)})
+		return true
+	})
+	slices.SortFunc(attrs, func(a, b slog.Attr) int { return strings.Compare(a.Key, b.Key) })
+	return attrs
+}
+
 func extractTraceSpanID(ctx context.Context, withTraceID bool, withSpanID bool) []slog.Attr {
 	if !withTraceID && !withSpanID {
 		return []slog.Attr{}
@@ -39,7 +39,19 @@ func NewMiddleware(logger *slog.Logger, config Config) func(http.Handler) http.H
 				r = r.WithContext(NewContextAttributes(r.Context()))
 			}
 
-			defer log(logger, config, r, bw, br, start, nil)
+			var end func(flush bool)
+			if config.Buffer != nil {
+				var ctx context.Context
+				ctx, end = config.Buffer(r.Context())
+				r = r.WithContext(ctx)
+			}
+
+			defer func() {
+				if end != nil {
+					end(config.statusLevel(bw.Status()) >= config.ServerErrorLevel)
+				}
+				log(logger, config, r, bw, br, start, nil)
+			}()
 
 			next.ServeHTTP(bw, r)
 		})
@@ -2,6 +2,7 @@ package sloghttp
 
 import (
 	"bytes"
+	"context"
 	"io"
 	"log/slog"
 	"net/http"
@@ -67,3 +68,30 @@ func TestNewMiddleware_RequestID_Logging_CustomAttr(t *testing.T) {
 	require.True(t, ok)
 	require.Equal(t, "bar", foo.Value.String())
 }
+
+func TestNewMiddleware_Buffer(t *testing.T) {
+	type key struct{}
+	var flushed []bool
+	cfg := DefaultConfig
+	cfg.ClientErrorLevel = slog.LevelError
+	cfg.Buffer = func(ctx context.Context) (context.Context, func(flush bool)) {
+		return context.WithValue(ctx, key{}, true), func(flush bool) { flushed = append(flushed, flush) }
+	}
+
+	mw := NewMiddleware(slog.New(&captureHandler{}), cfg)
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, true, r.Context().Value(key{}))
+		switch r.URL.Path {
+		case "/fail":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ok", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fail", nil))
+	// 404 logs at ClientErrorLevel, which reaches ServerErrorLevel here
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+	require.Equal(t, []bool{false, true, true}, flushed)
+}
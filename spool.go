@@ -0,0 +1,210 @@
+package logger
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// spool is a bounded FIFO of records, used under the lock of its NetWriter.
+type spool interface {
+	// push appends a copy of rec, evicting the oldest records beyond the size
+	// bound; ok is false when rec itself is dropped.
+	push(rec []byte) (evicted int, ok bool)
+	// peek returns the oldest records, at least one and at most maxBytes
+	// bytes when more; they stay valid after pop.
+	peek(maxBytes int) ([][]byte, error)
+	// pop removes the n oldest records.
+	pop(n int) error
+	len() int
+	close() error
+}
+
+// memSpool holds the records in memory.
+type memSpool struct {
+	max     int64
+	records [][]byte
+	bytes   int64
+}
+
+func (s *memSpool) push(rec []byte) (int, bool) {
+	if int64(len(rec)) > s.max {
+		return 0, false
+	}
+	s.records = append(s.records, append([]byte(nil), rec...))
+	s.bytes += int64(len(rec))
+	evicted := 0
+	for s.bytes > s.max {
+		s.bytes -= int64(len(s.records[evicted]))
+		s.records[evicted] = nil
+		evicted++
+	}
+	s.records = s.records[evicted:]
+	return evicted, true
+}
+
+func (s *memSpool) peek(maxBytes int) ([][]byte, error) {
+	n, size := 0, 0
+	for n < len(s.records) && (n == 0 || size+len(s.records[n]) <= maxBytes) {
+		size += len(s.records[n])
+		n++
+	}
+	return s.records[:n:n], nil
+}
+
+func (s *memSpool) pop(n int) error {
+	for i := range min(n, len(s.records)) {
+		s.bytes -= int64(len(s.records[i]))
+		s.records[i] = nil
+	}
+	s.records = s.records[min(n, len(s.records)):]
+	return nil
+}
+
+func (s *memSpool) len() int     { return len(s.records) }
+func (s *memSpool) close() error { return nil }
+
+// diskSpool holds the records in a file, each prefixed with its 4 bytes big
+// endian length. The records left when it is closed are loaded by the next
+// diskSpool of the same file.
+type diskSpool struct {
+	f       *os.File
+	max     int64
+	lengths []int // of the records from head
+	head    int64 // offset of the oldest record
+	end     int64
+	bytes   int64 // pending bytes, prefixes included
+}
+
+// openDiskSpool opens the spool file of the network address in dir, keeping
+// the complete records it holds.
+func openDiskSpool(dir, network, address string, max int64) (*diskSpool, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create spool dir: %w", err)
+	}
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '.' {
+			return r
+		}
+		return '_'
+	}, network+"-"+address)
+	f, err := os.OpenFile(filepath.Join(dir, name+".spool"), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open spool file: %w", err)
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("open spool file: %w", err)
+	}
+	s := &diskSpool{f: f, max: max}
+	var prefix [4]byte
+	for {
+		if _, err := f.ReadAt(prefix[:], s.end); err != nil {
+			break
+		}
+		n := int64(binary.BigEndian.Uint32(prefix[:]))
+		if s.end+4+n > fi.Size() {
+			break // truncated by a crash
+		}
+		s.lengths = append(s.lengths, int(n))
+		s.end += 4 + n
+		s.bytes += 4 + n
+	}
+	if err := f.Truncate(s.end); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("truncate spool file: %w", err)
+	}
+	s.evict()
+	return s, nil
+}
+
+func (s *diskSpool) push(rec []byte) (int, bool) {
+	if int64(len(rec))+4 > s.max {
+		return 0, false
+	}
+	buf := make([]byte, 4, 4+len(rec))
+	binary.BigEndian.PutUint32(buf, uint32(len(rec)))
+	if _, err := s.f.WriteAt(append(buf, rec...), s.end); err != nil {
+		return 0, false
+	}
+	s.lengths = append(s.lengths, len(rec))
+	s.end += int64(len(buf) + len(rec))
+	s.bytes += int64(len(buf) + len(rec))
+	return s.evict(), true
+}
+
+// evict drops the oldest records beyond the size bound.
+func (s *diskSpool) evict() int {
+	n := 0
+	for s.bytes > s.max {
+		s.head += 4 + int64(s.lengths[n])
+		s.bytes -= 4 + int64(s.lengths[n])
+		n++
+	}
+	s.lengths = s.lengths[n:]
+	return n
+}
+
+func (s *diskSpool) peek(maxBytes int) ([][]byte, error) {
+	n, size := 0, 0
+	for n < len(s.lengths) && (n == 0 || size+4+s.lengths[n] <= maxBytes) {
+		size += 4 + s.lengths[n]
+		n++
+	}
+	buf := make([]byte, size)
+	if _, err := s.f.ReadAt(buf, s.head); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("read spool file: %w", err)
+	}
+	records := make([][]byte, n)
+	for i := range records {
+		records[i] = buf[4 : 4+s.lengths[i] : 4+s.lengths[i]]
+		buf = buf[4+s.lengths[i]:]
+	}
+	return records, nil
+}
+
+func (s *diskSpool) pop(n int) error {
+	for _, l := range s.lengths[:min(n, len(s.lengths))] {
+		s.head += 4 + int64(l)
+		s.bytes -= 4 + int64(l)
+	}
+	s.lengths = s.lengths[min(n, len(s.lengths)):]
+	if len(s.lengths) == 0 || s.head > s.max {
+		return s.compact()
+	}
+	return nil
+}
+
+// compact moves the pending records to the start of the file.
+func (s *diskSpool) compact() error {
+	if s.head == 0 {
+		return nil
+	}
+	buf := make([]byte, s.bytes)
+	if _, err := s.f.ReadAt(buf, s.head); err != nil && err != io.EOF {
+		return fmt.Errorf("read spool file: %w", err)
+	}
+	if _, err := s.f.WriteAt(buf, 0); err != nil {
+		return fmt.Errorf("write spool file: %w", err)
+	}
+	s.head, s.end = 0, s.bytes
+	if err := s.f.Truncate(s.end); err != nil {
+		return fmt.Errorf("truncate spool file: %w", err)
+	}
+	return nil
+}
+
+func (s *diskSpool) len() int { return len(s.lengths) }
+
+func (s *diskSpool) close() error {
+	err := s.compact()
+	if cerr := s.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,105 @@
+package logger
+
+import (
+	"context"
+	"io"
+	"log"
+	"log/slog"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+var stdlib struct {
+	sync.Mutex
+	owner *slog.Logger // logger the std library is routed to, nil when not captured
+
+	// state before the first capture, restored when the owner releases it
+	slog   *slog.Logger
+	output io.Writer
+	flags  int
+	prefix string
+}
+
+// CaptureStdlib routes the output of the log package, at the given level, and
+// of slog.Default() to l. The log package prefix and flags are cleared, l adds
+// its own time and source. The returned function restores the previous state,
+// unless another call to CaptureStdlib took over in the meantime, in which case
+// the restoring is left to it.
+func CaptureStdlib(l *slog.Logger, level slog.Level) (restore func()) {
+	stdlib.Lock()
+	defer stdlib.Unlock()
+	if stdlib.owner == nil {
+		stdlib.slog = slog.Default()
+		stdlib.output = log.Writer()
+		stdlib.flags = log.Flags()
+		stdlib.prefix = log.Prefix()
+	}
+	stdlib.owner = l
+
+	// slog.SetDefault redirects the log package to l at INFO, override it
+	slog.SetDefault(l)
+	log.SetOutput(&stdWriter{h: l.Handler(), level: level})
+	log.SetFlags(0)
+	log.SetPrefix("")
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			stdlib.Lock()
+			defer stdlib.Unlock()
+			if stdlib.owner != l {
+				return
+			}
+			stdlib.owner = nil
+			slog.SetDefault(stdlib.slog)
+			log.SetOutput(stdlib.output)
+			log.SetFlags(stdlib.flags)
+			log.SetPrefix(stdlib.prefix)
+		})
+	}
+}
+
+// NewStdLogger returns a *log.Logger writing each line to l at level, for APIs
+// such as http.Server.ErrorLog.
+func NewStdLogger(l *slog.Logger, level slog.Level) *log.Logger {
+	return log.New(&stdWriter{h: l.Handler(), level: level}, "", 0)
+}
+
+// stdWriter turns the lines written by a *log.Logger into records.
+type stdWriter struct {
+	h     slog.Handler
+	level slog.Level
+}
+
+func (w *stdWriter) Write(p []byte) (int, error) {
+	ctx := context.Background()
+	if !w.h.Enabled(ctx, w.level) {
+		return len(p), nil
+	}
+	r := slog.NewRecord(time.Now(), w.level, strings.TrimSuffix(string(p), "\n"), callerOutsideLog())
+	return len(p), w.h.Handle(ctx, r)
+}
+
+// callerOutsideLog returns the pc of the first caller of stdWriter.Write
+// outside package log, whichever path through log led to the write.
+func callerOutsideLog() uintptr {
+	var pcs [16]uintptr
+	n := runtime.Callers(3, pcs[:]) // skip [Callers, callerOutsideLog, Write]
+	for i := range n {
+		frame, _ := runtime.CallersFrames(pcs[i : i+1]).Next()
+		if !strings.HasPrefix(frame.Function, "log.") {
+			return pcs[i]
+		}
+	}
+	return 0
+}
+
+// closeFunc adapts a function to io.Closer.
+type closeFunc func()
+
+func (f closeFunc) Close() error {
+	f()
+	return nil
+}
@@ -0,0 +1,74 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"log/slog"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogger_CaptureStdlib(t *testing.T) {
+	prevSlog, prevOutput, prevFlags := slog.Default(), log.Writer(), log.Flags()
+
+	var buf bytes.Buffer
+	l, err := Open(&Config{Mode: ModeCustom, Writer: &buf, Detail: true, CaptureStdlib: true, StdlibLevel: slog.LevelWarn})
+	require.NoError(t, err)
+
+	log.Printf("from %s", "log")
+	slog.Info("from slog")
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `"level":"WARN"`)
+	assert.Contains(t, lines[0], `"file":"`)
+	assert.Contains(t, lines[0], `stdlib_test.go"`)
+	assert.Contains(t, lines[0], `"msg":"from log"}`)
+	assert.Contains(t, lines[1], `"msg":"from slog"`)
+
+	require.NoError(t, l.Close())
+	assert.Same(t, prevSlog, slog.Default())
+	assert.Equal(t, prevOutput, log.Writer())
+	assert.Equal(t, prevFlags, log.Flags())
+}
+
+func TestCaptureStdlib_TakeOver(t *testing.T) {
+	prevSlog := slog.Default()
+
+	var buf1, buf2 bytes.Buffer
+	l1 := slog.New(slog.NewTextHandler(&buf1, nil))
+	l2 := slog.New(slog.NewTextHandler(&buf2, nil))
+	restore1 := CaptureStdlib(l1, slog.LevelInfo)
+	restore2 := CaptureStdlib(l2, slog.LevelInfo)
+
+	// l2 took over, releasing l1 keeps it in place
+	restore1()
+	log.Print("hello")
+	assert.Empty(t, buf1.String())
+	assert.Contains(t, buf2.String(), "msg=hello")
+
+	restore2()
+	assert.Same(t, prevSlog, slog.Default())
+}
+
+func TestNewStdLogger(t *testing.T) {
+	var buf bytes.Buffer
+	std := NewStdLogger(New(&Config{Mode: ModeCustom, Writer: &buf, Format: FormatLogfmt}), slog.LevelError)
+	std.Println("http: TLS handshake error")
+	assert.Contains(t, buf.String(), `level=ERROR msg="http: TLS handshake error"`+"\n")
+
+	buf.Reset()
+	std = NewStdLogger(New(&Config{Mode: ModeCustom, Writer: &buf, Format: FormatLogfmt, Detail: true}), slog.LevelError)
+	_, file, line, _ := runtime.Caller(0)
+	require.NoError(t, std.Output(1, "direct"))
+	assert.Contains(t, buf.String(), fmt.Sprintf("source=%s:%d", file, line+1))
+
+	buf.Reset()
+	std = NewStdLogger(New(&Config{Mode: ModeCustom, Writer: &buf}), slog.LevelDebug)
+	std.Print("hidden")
+	assert.Empty(t, buf.String())
+}
@@ -0,0 +1,361 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Syslog message formats.
+const (
+	RFC5424 = "5424"
+	RFC3164 = "3164"
+)
+
+// SyslogSDID is the default ID of the structured data element holding the
+// attrs, 32473 is the private enterprise number reserved for documentation.
+const SyslogSDID = "attrs@32473"
+
+var syslogFacilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5, "lpr": 6, "news": 7,
+	"uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11, "ntp": 12, "audit": 13, "alert": 14, "clock": 15,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19, "local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// SyslogConfig configures a syslog output. The embedded NetworkConfig sets the
+// transport: unixgram to /dev/log by default, udp when only Address is set, tcp
+// with octet-counting framing; reconnects and spooling work as in ModeNetwork.
+type SyslogConfig struct {
+	NetworkConfig `yaml:",inline"`
+
+	RFC      string `json:"rfc" yaml:"rfc"`           // 5424 or 3164, default 5424
+	Facility string `json:"facility" yaml:"facility"` // user, daemon, local0-local7..., default user
+	AppName  string `json:"app_name" yaml:"app_name"` // default the program name
+	ProcID   string `json:"proc_id" yaml:"proc_id"`   // default the pid
+	MsgID    string `json:"msg_id" yaml:"msg_id"`     // RFC 5424 only, default none
+	Hostname string `json:"hostname" yaml:"hostname"` // default os.Hostname()
+	// JSON renders the message and attrs as a JSON object in MSG instead of
+	// RFC 5424 structured data, or logfmt attrs after the message for RFC 3164.
+	JSON bool   `json:"json" yaml:"json"`
+	SDID string `json:"sd_id" yaml:"sd_id"` // ID of the structured data element, default attrs@32473
+}
+
+// withDefaults returns c with the defaults of its empty settings.
+func (c SyslogConfig) withDefaults() SyslogConfig {
+	if c.Network == "" {
+		c.Network = "unixgram"
+		if c.Address != "" {
+			c.Network = "udp"
+		}
+	}
+	if c.Address == "" && c.Network == "unixgram" {
+		c.Address = "/dev/log"
+	}
+	if c.RFC == "" {
+		c.RFC = RFC5424
+	}
+	if c.Facility == "" {
+		c.Facility = "user"
+	}
+	if c.AppName == "" {
+		c.AppName = filepath.Base(os.Args[0])
+	}
+	if c.ProcID == "" {
+		c.ProcID = strconv.Itoa(os.Getpid())
+	}
+	if c.Hostname == "" {
+		c.Hostname, _ = os.Hostname()
+	}
+	if c.SDID == "" {
+		c.SDID = SyslogSDID
+	}
+	return c
+}
+
+// SyslogHandler writes records as syslog messages, RFC 5424 by default or
+// RFC 3164, through a NetWriter.
+//
+//	<14>1 2024-01-02T15:04:05.000000+08:00 web-1 billing 42 - [attrs@32473 user.id="7"] order created
+//
+// Levels map to the severities debug (below INFO), info, warning, err and
+// crit (ERROR+4 and above).
+type SyslogHandler struct {
+	state  *syslogState
+	json   slog.Handler // renders the MSG of JSON mode into state.buf, under state.mu
+	attrs  []slog.Attr  // flattened attrs of the logger
+	prefix string       // dotted prefix of the open groups
+}
+
+type syslogState struct {
+	conf     SyslogConfig
+	opts     slog.HandlerOptions
+	facility int
+	w        *NetWriter
+	mu       sync.Mutex
+	buf      bytes.Buffer
+}
+
+// NewSyslogHandler creates a SyslogHandler, Close it to flush and close the
+// connection.
+func NewSyslogHandler(conf SyslogConfig, opts *slog.HandlerOptions) (*SyslogHandler, error) {
+	conf = conf.withDefaults()
+	facility, ok := syslogFacilities[conf.Facility]
+	if !ok {
+		return nil, fmt.Errorf("unknown syslog facility %q", conf.Facility)
+	}
+	if conf.RFC != RFC5424 && conf.RFC != RFC3164 {
+		return nil, fmt.Errorf("unknown syslog rfc %q", conf.RFC)
+	}
+
+	var framing netFraming
+	if !isDatagram(conf.Network) {
+		// RFC 6587 octet counting
+		framing.frame = func(dst, rec []byte) []byte {
+			dst = strconv.AppendInt(dst, int64(len(rec)), 10)
+			return append(append(dst, ' '), rec...)
+		}
+	}
+	w, err := newNetWriter(conf.NetworkConfig, framing)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &syslogState{conf: conf, facility: facility, w: w}
+	if opts != nil {
+		s.opts = *opts
+	}
+	h := &SyslogHandler{state: s}
+	if conf.JSON {
+		jsonOpts := s.opts
+		jsonOpts.Level = levelAll
+		replace := jsonOpts.ReplaceAttr
+		jsonOpts.ReplaceAttr = func(groups []string, a slog.Attr) slog.Attr {
+			// the header holds the time and the level
+			if len(groups) == 0 && (a.Key == slog.TimeKey || a.Key == slog.LevelKey) {
+				return slog.Attr{}
+			}
+			if replace != nil {
+				return replace(groups, a)
+			}
+			return a
+		}
+		h.json = slog.NewJSONHandler(&s.buf, &jsonOpts)
+	}
+	return h, nil
+}
+
+func (h *SyslogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	min := slog.LevelInfo
+	if h.state.opts.Level != nil {
+		min = h.state.opts.Level.Level()
+	}
+	return level >= min
+}
+
+func (h *SyslogHandler) Handle(ctx context.Context, r slog.Record) error {
+	s := h.state
+	conf := &s.conf
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.buf.Reset()
+	if h.json != nil {
+		if err := h.json.Handle(ctx, r); err != nil {
+			return err
+		}
+	}
+	msg := bytes.TrimSuffix(s.buf.Bytes(), []byte("\n"))
+
+	buf := make([]byte, 0, 256+len(msg))
+	buf = append(buf, '<')
+	buf = strconv.AppendInt(buf, int64(s.facility*8+syslogSeverity(r.Level)), 10)
+	buf = append(buf, '>')
+	if conf.RFC == RFC3164 {
+		buf = r.Time.AppendFormat(buf, time.Stamp)
+		buf = append(buf, ' ')
+		buf = appendSyslogField(buf, conf.Hostname, 255)
+		buf = append(buf, ' ')
+		buf = appendSyslogField(buf, conf.AppName, 32)
+		buf = append(buf, '[')
+		buf = appendSyslogField(buf, conf.ProcID, 128)
+		buf = append(buf, "]: "...)
+		if h.json != nil {
+			return s.write(append(buf, msg...))
+		}
+		buf = append(buf, r.Message...)
+		for _, a := range h.recordAttrs(r) {
+			buf = append(buf, ' ')
+			buf = appendLogfmtKey(buf, a.Key)
+			buf = append(buf, '=')
+			buf = appendLogfmtValue(buf, a.Value)
+		}
+		return s.write(buf)
+	}
+
+	buf = append(buf, '1', ' ')
+	buf = r.Time.AppendFormat(buf, "2006-01-02T15:04:05.000000Z07:00")
+	for _, field := range []struct {
+		value string
+		max   int
+	}{{conf.Hostname, 255}, {conf.AppName, 48}, {conf.ProcID, 128}, {conf.MsgID, 32}} {
+		buf = append(buf, ' ')
+		buf = appendSyslogField(buf, field.value, field.max)
+	}
+	buf = append(buf, ' ')
+	if h.json != nil {
+		buf = append(buf, '-', ' ')
+		return s.write(append(buf, msg...))
+	}
+
+	attrs := h.recordAttrs(r)
+	if len(attrs) == 0 {
+		buf = append(buf, '-')
+	} else {
+		buf = append(buf, '[')
+		buf = appendSyslogField(buf, conf.SDID, 32)
+		for _, a := range attrs {
+			buf = append(buf, ' ')
+			buf = appendSDName(buf, a.Key)
+			buf = append(buf, '=', '"')
+			buf = appendSDValue(buf, a.Value.String())
+			buf = append(buf, '"')
+		}
+		buf = append(buf, ']')
+	}
+	if r.Message != "" {
+		buf = append(buf, ' ')
+		buf = append(buf, r.Message...)
+	}
+	return s.write(buf)
+}
+
+func (s *syslogState) write(msg []byte) error {
+	s.w.push(msg)
+	return nil
+}
+
+// recordAttrs returns the flattened attrs of the logger and r, with the
+// source first when AddSource is set.
+func (h *SyslogHandler) recordAttrs(r slog.Record) []slog.Attr {
+	var attrs []slog.Attr
+	if h.state.opts.AddSource && r.PC != 0 {
+		src := recordSource(r)
+		attrs = append(attrs, slog.String(slog.SourceKey, src.File+":"+strconv.Itoa(src.Line)))
+	}
+	attrs = append(attrs, h.attrs...)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = appendFlatAttr(attrs, h.prefix, a)
+		return true
+	})
+
+	replace := h.state.opts.ReplaceAttr
+	if replace == nil {
+		return attrs
+	}
+	replaced := attrs[:0]
+	for _, a := range attrs {
+		if a = replace(nil, a); a.Key != "" {
+			replaced = append(replaced, a)
+		}
+	}
+	return replaced
+}
+
+func (h *SyslogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.attrs = slices.Clip(h.attrs)
+	for _, a := range attrs {
+		h2.attrs = appendFlatAttr(h2.attrs, h.prefix, a)
+	}
+	if h.json != nil {
+		h2.json = h.json.WithAttrs(attrs)
+	}
+	return &h2
+}
+
+func (h *SyslogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.prefix = h.prefix + name + "."
+	if h.json != nil {
+		h2.json = h.json.WithGroup(name)
+	}
+	return &h2
+}
+
+// Sync waits until the spooled messages are sent.
+func (h *SyslogHandler) Sync() error { return h.state.w.Sync() }
+
+// Dropped returns the number of messages dropped by the writer, see NetWriter.Dropped.
+func (h *SyslogHandler) Dropped() uint64 { return h.state.w.Dropped() }
+
+// Close sends the spooled messages and closes the connection.
+func (h *SyslogHandler) Close() error { return h.state.w.Close() }
+
+func syslogSeverity(level slog.Level) int {
+	switch {
+	case level < slog.LevelInfo:
+		return 7 // debug
+	case level < slog.LevelWarn:
+		return 6 // info
+	case level < slog.LevelError:
+		return 4 // warning
+	case level < slog.LevelError+4:
+		return 3 // err
+	default:
+		return 2 // crit
+	}
+}
+
+// appendSyslogField appends a header field: printable US-ASCII, at most max
+// bytes, - when empty.
+func appendSyslogField(buf []byte, s string, max int) []byte {
+	if s == "" {
+		return append(buf, '-')
+	}
+	for i := 0; i < len(s) && i < max; i++ {
+		c := s[i]
+		if c <= ' ' || c > '~' {
+			c = '_'
+		}
+		buf = append(buf, c)
+	}
+	return buf
+}
+
+// appendSDName appends an SD-PARAM name, which excludes =, space, ] and ".
+func appendSDName(buf []byte, s string) []byte {
+	if s == "" {
+		return append(buf, '_')
+	}
+	for i := 0; i < len(s) && i < 32; i++ {
+		c := s[i]
+		if c <= ' ' || c > '~' || c == '=' || c == ']' || c == '"' {
+			c = '_'
+		}
+		buf = append(buf, c)
+	}
+	return buf
+}
+
+// appendSDValue appends an SD-PARAM value, escaping ", \ and ].
+func appendSDValue(buf []byte, s string) []byte {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"', '\\', ']':
+			buf = append(buf, '\\', c)
+		default:
+			buf = append(buf, c)
+		}
+	}
+	return buf
+}
@@ -0,0 +1,163 @@
+package logger
+
+import (
+	"bufio"
+	"io"
+	"log/slog"
+	"net"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var syslogTime = regexp.MustCompile(`^(<\d+>1 )\S+`)
+
+func readDatagrams(t *testing.T, pc net.PacketConn, n int) []string {
+	t.Helper()
+	var msgs []string
+	buf := make([]byte, 4096)
+	for range n {
+		_ = pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+		m, _, err := pc.ReadFrom(buf)
+		require.NoError(t, err)
+		msgs = append(msgs, string(buf[:m]))
+	}
+	return msgs
+}
+
+func TestSyslogHandler_RFC5424(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer pc.Close()
+
+	h, err := NewSyslogHandler(SyslogConfig{
+		NetworkConfig: NetworkConfig{Address: pc.LocalAddr().String()},
+		Facility:      "local0",
+		AppName:       "billing",
+		ProcID:        "42",
+		MsgID:         "order",
+		Hostname:      "web 1",
+	}, &slog.HandlerOptions{Level: slog.LevelDebug})
+	require.NoError(t, err)
+	defer h.Close()
+
+	l := slog.New(h).With("user", slog.GroupValue(slog.Int("id", 7)))
+	l.Info("order created", "note", `say "hi" [ok]`, "path", `c:\tmp`)
+	l.WithGroup("req").Debug("debug", "id", 1)
+	l.Log(t.Context(), slog.LevelError+4, "")
+	require.NoError(t, h.Sync())
+
+	msgs := readDatagrams(t, pc, 3)
+	for i := range msgs {
+		msgs[i] = syslogTime.ReplaceAllString(msgs[i], "${1}TS")
+	}
+	assert.Equal(t, `<134>1 TS web_1 billing 42 order [attrs@32473 user.id="7" note="say \"hi\" [ok\]" path="c:\\tmp"] order created`, msgs[0])
+	assert.Equal(t, `<135>1 TS web_1 billing 42 order [attrs@32473 user.id="7" req.id="1"] debug`, msgs[1])
+	assert.Equal(t, `<130>1 TS web_1 billing 42 order [attrs@32473 user.id="7"]`, msgs[2])
+}
+
+func TestSyslogHandler_RFC3164(t *testing.T) {
+	pc, err := net.ListenPacket("unixgram", filepath.Join(t.TempDir(), "log"))
+	require.NoError(t, err)
+	defer pc.Close()
+
+	conf := SyslogConfig{
+		NetworkConfig: NetworkConfig{Network: "unixgram", Address: pc.LocalAddr().String()},
+		RFC:           RFC3164,
+		Facility:      "daemon",
+		AppName:       "billing",
+		ProcID:        "42",
+		Hostname:      "web-1",
+	}
+	h, err := NewSyslogHandler(conf, nil)
+	require.NoError(t, err)
+	defer h.Close()
+	slog.New(h).Warn("disk low", "free", "1 GB", "pct", 3)
+	require.NoError(t, h.Sync())
+
+	conf.JSON = true
+	hj, err := NewSyslogHandler(conf, nil)
+	require.NoError(t, err)
+	defer hj.Close()
+	slog.New(hj).With("pct", 3).Error("disk full")
+	require.NoError(t, hj.Sync())
+
+	msgs := readDatagrams(t, pc, 2)
+	stamp := regexp.MustCompile(`^(<\d+>)\w{3} [ \d]\d \d\d:\d\d:\d\d `)
+	assert.Equal(t, `<28>web-1 billing[42]: disk low free="1 GB" pct=3`, stamp.ReplaceAllString(msgs[0], "$1"))
+	assert.Equal(t, `<27>web-1 billing[42]: {"msg":"disk full","pct":3}`, stamp.ReplaceAllString(msgs[1], "$1"))
+}
+
+func TestSyslogHandler_TCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		// octet counting: LEN SP MSG
+		r := bufio.NewReader(conn)
+		var msgs []string
+		for len(msgs) < 2 {
+			n, err := r.ReadString(' ')
+			if err != nil {
+				return
+			}
+			size, _ := strconv.Atoi(strings.TrimSpace(n))
+			msg := make([]byte, size)
+			if _, err := io.ReadFull(r, msg); err != nil {
+				return
+			}
+			msgs = append(msgs, string(msg))
+		}
+		received <- msgs
+	}()
+
+	l, err := Open(&Config{
+		Mode:   ModeSyslog,
+		Syslog: &SyslogConfig{NetworkConfig: NetworkConfig{Network: "tcp", Address: ln.Addr().String()}, AppName: "app", ProcID: "1", Hostname: "h", JSON: true},
+	})
+	require.NoError(t, err)
+	defer l.Close()
+	l.Info("first\nline")
+	l.Warn("second", "k", "v")
+	require.NoError(t, l.Sync())
+
+	select {
+	case msgs := <-received:
+		assert.Equal(t, `<14>1 TS h app 1 - - {"msg":"first\nline"}`, syslogTime.ReplaceAllString(msgs[0], "${1}TS"))
+		assert.Equal(t, `<12>1 TS h app 1 - - {"msg":"second","k":"v"}`, syslogTime.ReplaceAllString(msgs[1], "${1}TS"))
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+	}
+}
+
+func TestSyslogSeverity(t *testing.T) {
+	for level, want := range map[slog.Level]int{
+		slog.LevelDebug - 4: 7, slog.LevelDebug: 7, slog.LevelInfo: 6, slog.LevelInfo + 2: 6,
+		slog.LevelWarn: 4, slog.LevelError: 3, slog.LevelError + 4: 2, slog.LevelError + 8: 2,
+	} {
+		assert.Equal(t, want, syslogSeverity(level), level.String())
+	}
+}
+
+func TestConfig_ValidateSyslog(t *testing.T) {
+	assert.NoError(t, (&Config{Mode: ModeSyslog}).Validate())
+
+	err := (&Config{Mode: ModeSyslog, Syslog: &SyslogConfig{Facility: "local9", RFC: "1234"}}).Validate()
+	assert.ErrorContains(t, err, `unknown syslog facility "local9"`)
+	assert.ErrorContains(t, err, `unknown syslog rfc "1234"`)
+
+	_, err = NewSyslogHandler(SyslogConfig{Facility: "nope"}, nil)
+	assert.ErrorContains(t, err, `unknown syslog facility "nope"`)
+}